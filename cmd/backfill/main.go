@@ -0,0 +1,54 @@
+// Command backfill computes next_fire_at for events saved before the
+// scheduler started maintaining it. The cron picks such events up as well,
+// but running this once after deploying spares it the work.
+package main
+
+import (
+	"context"
+	"event-service/config"
+	"event-service/internal/event"
+	"log"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	cfg := config.LoadConfig()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		log.Fatalf("Failed to ping MongoDB: %v", err)
+	}
+
+	eventRepository, err := event.NewEventRepository(client.Database(cfg.MongoDB).Collection("events"))
+	if err != nil {
+		log.Fatalf("Failed to create event indexes: %v", err)
+	}
+
+	count, err := event.BackfillNextFireAt(context.Background(), eventRepository)
+	if err != nil {
+		log.Fatalf("Backfill failed after %d events: %v", count, err)
+	}
+
+	log.Printf("✅ Backfilled next_fire_at for %d events", count)
+}
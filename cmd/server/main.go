@@ -4,7 +4,12 @@ import (
 	"context"
 	"event-service/config"
 	"event-service/internal/event"
+	"event-service/internal/feed"
+	"event-service/internal/leader"
+	"event-service/internal/middleware"
+	"event-service/internal/notifier"
 	"event-service/internal/user"
+	"event-service/internal/webhook"
 	"event-service/pkg/constants"
 	"event-service/pkg/consul"
 	"event-service/pkg/firebase"
@@ -55,17 +60,104 @@ func main() {
 	c := cron.New(cron.WithSeconds())
 	client, _, _ := firebase.SetUpFireBase()
 	userService := user.NewUserService(consulClient)
+	webhookCollection := mongoClient.Database(cfg.MongoDB).Collection("webhooks")
+	webhookRepository, err := webhook.NewWebhookRepository(webhookCollection)
+	if err != nil {
+		logger.Fatalf("Failed to create webhook indexes: %v", err)
+	}
+	webhookService := webhook.NewWebhookService(webhookRepository)
+	webhookHandler := webhook.NewWebhookHandler(webhookService)
+	channels := []notifier.Notifier{
+		notifier.NewFCMNotifier(client, userService),
+		webhook.NewNotifier(webhookRepository, webhook.NewHTTPClient(10*time.Second)),
+	}
+	if cfg.SMTP.Host != "" {
+		channels = append(channels, notifier.NewEmailNotifier(cfg.SMTP, userService))
+	} else {
+		log.Println("⚠️ SMTP_HOST not set, email reminders disabled")
+	}
+	notifiers := notifier.NewRegistry(channels...)
 	eventCollection := mongoClient.Database(cfg.MongoDB).Collection("events")
-	eventRepository := event.NewEventRepository(eventCollection)
-	eventService := event.NewEventService(eventRepository, client, userService)
+	eventRepository, err := event.NewEventRepository(eventCollection)
+	if err != nil {
+		logger.Fatalf("Failed to create event indexes: %v", err)
+	}
+	escalationCollection := mongoClient.Database(cfg.MongoDB).Collection("reminder_escalations")
+	escalationRepository, err := event.NewEscalationRepository(escalationCollection)
+	if err != nil {
+		logger.Fatalf("Failed to create escalation indexes: %v", err)
+	}
+	deliveryCollection := mongoClient.Database(cfg.MongoDB).Collection("notification_deliveries")
+	deliveryRepository, err := event.NewDeliveryRepository(deliveryCollection)
+	if err != nil {
+		logger.Fatalf("Failed to create delivery indexes: %v", err)
+	}
+	dispatchCollection := mongoClient.Database(cfg.MongoDB).Collection("reminder_dispatches")
+	dispatchRepository, err := event.NewDispatchRepository(dispatchCollection)
+	if err != nil {
+		logger.Fatalf("Failed to create dispatch indexes: %v", err)
+	}
+	stateCollection := mongoClient.Database(cfg.MongoDB).Collection("scheduler_state")
+	stateRepository := event.NewSchedulerStateRepository(stateCollection)
+	retryCollection := mongoClient.Database(cfg.MongoDB).Collection("notification_retries")
+	retryRepository, err := event.NewRetryRepository(retryCollection)
+	if err != nil {
+		logger.Fatalf("Failed to create retry indexes: %v", err)
+	}
+	maxLateness, err := time.ParseDuration(cfg.Lateness)
+	if err != nil {
+		logger.Fatalf("Invalid MAX_REMINDER_LATENESS: %v", err)
+	}
+	if !event.IsSupportedLocale(cfg.Locale) {
+		logger.Fatalf("Invalid DEFAULT_LOCALE: %s", cfg.Locale)
+	}
+	leaseCollection := mongoClient.Database(cfg.MongoDB).Collection("leases")
+	fence := leader.NewFence(leaseCollection)
+	eventService := event.NewEventService(eventRepository, escalationRepository, deliveryRepository, dispatchRepository, stateRepository, retryRepository, fence, notifiers, userService, maxLateness, cfg.Locale)
 	eventHandler := event.NewEventHandler(eventService)
+	feedCollection := mongoClient.Database(cfg.MongoDB).Collection("feed_tokens")
+	feedRepository, err := feed.NewFeedRepository(feedCollection)
+	if err != nil {
+		logger.Fatalf("Failed to create feed indexes: %v", err)
+	}
+	feedService := feed.NewFeedService(feedRepository, eventService, cfg.BaseURL)
+	feedHandler := feed.NewFeedHandler(feedService)
+	leaseTTL, err := time.ParseDuration(cfg.LeaseTTL)
+	if err != nil {
+		logger.Fatalf("Invalid LEADER_LEASE_TTL: %v", err)
+	}
+	if leaseTTL < leader.MinLeaseTTL {
+		logger.Fatalf("Invalid LEADER_LEASE_TTL: must be at least %s", leader.MinLeaseTTL)
+	}
+	leaseRepository := leader.NewLeaseRepository(leaseCollection)
+	leaderService := leader.NewLeaderService(leaseRepository, "event-cron", leaseTTL)
+	leaderHandler := leader.NewLeaderHandler(leaderService)
+
+	leaderCtx, stopLeader := context.WithCancel(context.Background())
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		leaderService.Run(leaderCtx)
+	}()
+
+	adminOnly := middleware.RequireRole(userService, cfg.Admin)
 
 	router := gin.Default()
-	event.RegisterRoutes(router, eventHandler)
+	event.RegisterRoutes(router, eventHandler, adminOnly)
+	feed.RegisterRoutes(router, feedHandler)
+	leader.RegisterRoutes(router, leaderHandler, adminOnly)
+	webhook.RegisterRoutes(router, webhookHandler)
 
 	_, err = c.AddFunc("0 */1 * * * *", func() {
+		lease, ok := leaderService.Current()
+		if !ok {
+			log.Println("⏸️ Not the scheduler leader, skipping tick")
+			return
+		}
+
 		log.Println("🔄 Cron master running...")
 		ctx := context.WithValue(context.Background(), constants.TokenKey, os.Getenv("CRON_SERVICE_TOKEN"))
+		ctx = leader.WithLease(ctx, lease)
 		if err := eventService.CronEventNotifications(ctx); err != nil {
 			log.Printf("CronEventNotifications failed: %v", err)
 		}
@@ -96,6 +188,10 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	c.Stop()
+	stopLeader()
+	<-leaderDone
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
@@ -11,6 +11,14 @@ type Registry struct {
 	Host string `mapstructure:"host" validate:"required"`
 }
 
+type SMTP struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
 type AppConfiguration struct {
 	Name        string    `mapstructure:"name"`
 	Version     string    `mapstructure:"version"`
@@ -51,8 +59,14 @@ type Config struct {
 	Port     string
 	MongoURI string
 	MongoDB  string
+	BaseURL  string
+	LeaseTTL string
+	Lateness string
+	Locale   string
+	Admin    string
 	Consul   Consul           `mapstructure:"consul" validate:"required"`
 	Registry Registry         `mapstructure:"registry" validate:"required"`
+	SMTP     SMTP             `mapstructure:"smtp"`
 	App      AppConfiguration `mapstructure:"app"`
 	Zap      ZapConfig        `mapstructure:"zap"`
 }
@@ -62,6 +76,11 @@ func LoadConfig() *Config {
 		Port:     getEnv("PORT", "8000"),
 		MongoURI: getEnv("MONGO_URI", "mongodb://localhost:27012"),
 		MongoDB:  getEnv("MONGO_DB", "portal"),
+		BaseURL:  getEnv("PUBLIC_BASE_URL", ""),
+		LeaseTTL: getEnv("LEADER_LEASE_TTL", "15s"),
+		Lateness: getEnv("MAX_REMINDER_LATENESS", "15m"),
+		Locale:   getEnv("DEFAULT_LOCALE", "vi"),
+		Admin:    getEnv("ADMIN_ROLE", "admin"),
 		Consul: Consul{
 			Host: getEnv("CONSUL_HOST", "localhost"),
 			Port: getEnv("CONSUL_PORT", "8500"),
@@ -69,6 +88,13 @@ func LoadConfig() *Config {
 		Registry: Registry{
 			Host: getEnv("REGISTRY_HOST", "localhost"),
 		},
+		SMTP: SMTP{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", ""),
+		},
 		App: AppConfiguration{
 			API: APIConfig{
 				Rest: RestConfig{
@@ -0,0 +1,16 @@
+package helper
+
+import (
+	"event-service/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestUserID returns the user set by middleware.Secured from the JWT,
+// falling back to the user_id query parameter when the token carries none.
+func RequestUserID(c *gin.Context) string {
+	if userID := c.GetString(constants.UserID); userID != "" {
+		return userID
+	}
+	return c.Query("user_id")
+}
@@ -0,0 +1,152 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"event-service/internal/notifier"
+)
+
+// catalog is the notification text of one locale.
+type catalog struct {
+	Title      *template.Template
+	Body       *template.Template
+	Escalation *template.Template
+	// Relative renders the time left until an occurrence from the duration
+	// phrase, e.g. "in %s"; Now is used once the occurrence has started.
+	Relative       string
+	Now            string
+	Day            unit
+	Hour           unit
+	Minute         unit
+	DateFormat     string
+	DateTimeFormat string
+	// Labels is the text channels such as email add around the message.
+	Labels notifier.Labels
+}
+
+// unit is a time unit's singular and plural forms, each taking the count.
+type unit struct {
+	One   string
+	Other string
+}
+
+func (u unit) format(n int64) string {
+	if n == 1 {
+		return fmt.Sprintf(u.One, n)
+	}
+	return fmt.Sprintf(u.Other, n)
+}
+
+// catalogs holds the supported locales by language code.
+var catalogs = map[string]*catalog{
+	"vi": {
+		Title:          template.Must(template.New("title").Parse("🔔 {{.Name}}")),
+		Body:           template.Must(template.New("body").Parse("Nhắc nhở: {{.Name}} sắp bắt đầu!")),
+		Escalation:     template.Must(template.New("escalation").Parse("Cảnh báo: {{.Name}} chưa được xác nhận sau {{.Attempt}} lần nhắc!")),
+		Relative:       "sau %s",
+		Now:            "ngay bây giờ",
+		Day:            unit{One: "%d ngày", Other: "%d ngày"},
+		Hour:           unit{One: "%d giờ", Other: "%d giờ"},
+		Minute:         unit{One: "%d phút", Other: "%d phút"},
+		DateFormat:     "02/01/2006",
+		DateTimeFormat: "15:04 02/01/2006",
+		Labels: notifier.Labels{
+			Greeting:     "Xin chào,",
+			GreetingName: "Xin chào %s,",
+			Event:        "Sự kiện",
+			Time:         "Thời gian",
+			Note:         "Ghi chú",
+			Link:         "Liên kết",
+		},
+	},
+	"en": {
+		Title:          template.Must(template.New("title").Parse("🔔 {{.Name}}")),
+		Body:           template.Must(template.New("body").Parse("Reminder: {{.Name}} is starting soon!")),
+		Escalation:     template.Must(template.New("escalation").Parse("Alert: {{.Name}} is still unacknowledged after {{.Attempt}} reminders!")),
+		Relative:       "in %s",
+		Now:            "now",
+		Day:            unit{One: "%d day", Other: "%d days"},
+		Hour:           unit{One: "%d hour", Other: "%d hours"},
+		Minute:         unit{One: "%d minute", Other: "%d minutes"},
+		DateFormat:     "Jan 2, 2006",
+		DateTimeFormat: "3:04 PM, Jan 2, 2006",
+		Labels: notifier.Labels{
+			Greeting:     "Hello,",
+			GreetingName: "Hello %s,",
+			Event:        "Event",
+			Time:         "Time",
+			Note:         "Note",
+			Link:         "Link",
+		},
+	},
+}
+
+// fallbackLocale is used when the configured default is not supported.
+const fallbackLocale = "vi"
+
+// normalizeLocale reduces a locale such as "en-US" or "en_GB" to the
+// language code catalogs are keyed by.
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// IsSupportedLocale reports whether notifications can be rendered in locale.
+func IsSupportedLocale(locale string) bool {
+	_, ok := catalogs[normalizeLocale(locale)]
+	return ok
+}
+
+func supportedLocales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// validateLocale checks an event's locale override; empty means none.
+func validateLocale(locale string) (string, error) {
+	if locale == "" {
+		return "", nil
+	}
+	if !IsSupportedLocale(locale) {
+		return "", fmt.Errorf("invalid locale: must be one of %s", strings.Join(supportedLocales(), ", "))
+	}
+	return normalizeLocale(locale), nil
+}
+
+// relative renders the time left until an occurrence, rounded up to the
+// minute, e.g. "in 1 day 2 hours" or "in 15 minutes".
+func (c *catalog) relative(d time.Duration) string {
+
+	if d <= 0 {
+		return c.Now
+	}
+
+	minutes := int64((d + time.Minute - 1) / time.Minute)
+	days := minutes / (24 * 60)
+	hours := minutes % (24 * 60) / 60
+	minutes %= 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, c.Day.format(days))
+	}
+	if hours > 0 {
+		parts = append(parts, c.Hour.format(hours))
+	}
+	if minutes > 0 && days == 0 {
+		parts = append(parts, c.Minute.format(minutes))
+	}
+
+	return fmt.Sprintf(c.Relative, strings.Join(parts, " "))
+}
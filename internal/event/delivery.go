@@ -0,0 +1,90 @@
+package event
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Triggers recorded on deliveries, telling apart why a push was sent.
+const (
+	triggerReminder   = "reminder"
+	triggerSnooze     = "snooze"
+	triggerEscalation = "escalation"
+	triggerBackup     = "backup"
+	triggerManual     = "manual"
+	triggerRetry      = "retry"
+)
+
+const (
+	defaultDeliveryLimit = 100
+	maxDeliveryLimit     = 500
+)
+
+func (s *eventService) GetDeliveries(ctx context.Context, id string, limit int64) ([]*Delivery, error) {
+
+	if id == "" {
+		return nil, errors.New("event_id is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	if limit > maxDeliveryLimit {
+		limit = maxDeliveryLimit
+	}
+
+	return s.deliveryRepository.FindByEventID(ctx, objID, limit)
+}
+
+// recordDelivery stores one delivery attempt. An empty target records a send
+// that never reached a device, e.g. because the user has no tokens.
+func (s *eventService) recordDelivery(ctx context.Context, ev *Event, notice reminderNotice, userID string, channel string, target string, messageID string, sendErr error) {
+
+	delivery := &Delivery{
+		ID:        primitive.NewObjectID(),
+		EventID:   ev.ID,
+		RuleIndex: notice.RuleIndex,
+		Trigger:   notice.Trigger,
+		UserID:    userID,
+		Channel:   channel,
+		Late:      notice.Late,
+		Success:   sendErr == nil,
+		MessageID: messageID,
+		CreatedAt: time.Now(),
+	}
+
+	if !notice.Occurrence.IsZero() {
+		occ := notice.Occurrence
+		delivery.OccurrenceStart = &occ
+	}
+
+	if target != "" {
+		delivery.TokenFingerprint = tokenFingerprint(target)
+	}
+
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := s.deliveryRepository.Create(ctx, delivery); err != nil {
+		log.Printf("❌ Error recording delivery for event %s: %v", ev.EventName, err)
+	}
+}
+
+// tokenFingerprint identifies a device token (or other target) in logs
+// without storing it.
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,87 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deliveryRetention is how long delivery records are kept before Mongo
+// expires them.
+const deliveryRetention = 90 * 24 * time.Hour
+
+type DeliveryRepository interface {
+	Create(ctx context.Context, delivery *Delivery) error
+	FindByEventID(ctx context.Context, eventID primitive.ObjectID, limit int64) ([]*Delivery, error)
+}
+
+type deliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDeliveryRepository(collection *mongo.Collection) (DeliveryRepository, error) {
+	if err := EnsureDeliveryIndexes(context.Background(), collection); err != nil {
+		return nil, err
+	}
+	return &deliveryRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *deliveryRepository) Create(ctx context.Context, delivery *Delivery) error {
+
+	_, err := r.collection.InsertOne(ctx, delivery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindByEventID returns the event's most recent deliveries first.
+func (r *deliveryRepository) FindByEventID(ctx context.Context, eventID primitive.ObjectID, limit int64) ([]*Delivery, error) {
+
+	var deliveries []*Delivery
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"event_id": eventID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &deliveries)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func EnsureDeliveryIndexes(ctx context.Context, coll *mongo.Collection) error {
+
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "event_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().
+				SetName("by_event_created"),
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().
+				SetName("created_ttl").
+				SetExpireAfterSeconds(int32(deliveryRetention.Seconds())),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
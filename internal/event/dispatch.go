@@ -0,0 +1,60 @@
+package event
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"event-service/internal/leader"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// claimDispatches claims every due reminder in the dispatch ledger and
+// returns the ones this replica won. A reminder whose claim fails is not
+// sent, so a ledger outage never produces duplicates.
+func (s *eventService) claimDispatches(ctx context.Context, ev *Event, due []dueReminder) []dueReminder {
+
+	var claimed []dueReminder
+
+	for _, d := range due {
+		dispatch := &Dispatch{
+			ID:              primitive.NewObjectID(),
+			EventID:         ev.ID,
+			OccurrenceStart: d.Occurrence.Start,
+			RuleIndex:       d.RuleIndex,
+			FireAt:          d.FireAt.Add(time.Duration(d.Attempt) * time.Minute),
+			ClaimedBy:       s.instance,
+			LeaseToken:      leader.FencingToken(ctx),
+			CreatedAt:       time.Now(),
+		}
+
+		var won bool
+		err := s.fenced(ctx, func(ctx context.Context) (err error) {
+			won, err = s.dispatchRepository.Claim(ctx, dispatch)
+			return err
+		})
+		if err != nil {
+			log.Printf("❌ Error claiming dispatch for event %s (rule=%d): %v", ev.EventName, d.RuleIndex, err)
+			continue
+		}
+
+		if !won {
+			log.Printf("🔒 Event %s (rule=%d) already dispatched by another instance", ev.EventName, d.RuleIndex)
+			continue
+		}
+
+		claimed = append(claimed, d)
+	}
+
+	return claimed
+}
+
+// fenced runs a claim that precedes a send so that it only commits while
+// this replica still holds the scheduler lease carried by ctx.
+func (s *eventService) fenced(ctx context.Context, claim func(ctx context.Context) error) error {
+	if s.fence == nil {
+		return claim(ctx)
+	}
+	return s.fence.Do(ctx, claim)
+}
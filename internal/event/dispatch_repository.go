@@ -0,0 +1,84 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// dispatchRetention is how long ledger entries are kept. It only has to
+// outlive the window in which a reminder could be picked up again.
+const dispatchRetention = 7 * 24 * time.Hour
+
+type DispatchRepository interface {
+	Claim(ctx context.Context, dispatch *Dispatch) (bool, error)
+}
+
+type dispatchRepository struct {
+	collection *mongo.Collection
+}
+
+// NewDispatchRepository fails when the ledger's indexes cannot be created:
+// without the unique key every instance could claim the same send.
+func NewDispatchRepository(collection *mongo.Collection) (DispatchRepository, error) {
+	if err := EnsureDispatchIndexes(context.Background(), collection); err != nil {
+		return nil, err
+	}
+	return &dispatchRepository{
+		collection: collection,
+	}, nil
+}
+
+// Claim inserts the ledger entry unless its key is already taken and
+// reports whether this caller now owns the send. It upserts rather than
+// inserts, so a taken key is not an error and can be claimed inside a
+// fenced transaction, which any failed write would abort.
+func (r *dispatchRepository) Claim(ctx context.Context, dispatch *Dispatch) (bool, error) {
+
+	res, err := r.collection.UpdateOne(ctx,
+		bson.M{
+			"event_id":         dispatch.EventID,
+			"occurrence_start": dispatch.OccurrenceStart,
+			"rule_index":       dispatch.RuleIndex,
+			"fire_at":          dispatch.FireAt,
+		},
+		bson.M{"$setOnInsert": dispatch},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return res.UpsertedCount == 1, nil
+}
+
+func EnsureDispatchIndexes(ctx context.Context, coll *mongo.Collection) error {
+
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "event_id", Value: 1},
+				{Key: "occurrence_start", Value: 1},
+				{Key: "rule_index", Value: 1},
+				{Key: "fire_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("by_dispatch_key").
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().
+				SetName("created_ttl").
+				SetExpireAfterSeconds(int32(dispatchRetention.Seconds())),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
@@ -0,0 +1,217 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxEscalationAttempts caps EscalationPolicy.MaxAttempts.
+const maxEscalationAttempts = 50
+
+func (s *eventService) AcknowledgeOccurrence(ctx context.Context, id string, userID string, req *AcknowledgeRequest) error {
+
+	if id == "" {
+		return errors.New("event_id is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	ev, err := s.eventRepository.FindEventByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+
+	if ev == nil {
+		return errors.New("event not found")
+	}
+
+	now := time.Now().In(s.eventLocation(ev))
+
+	occ, err := s.resolveOccurrence(ev, req.Occurrence, now)
+	if err != nil {
+		return err
+	}
+
+	ack := &Acknowledgement{
+		OccurrenceStart: occ.Start,
+		AcknowledgedAt:  now,
+		AcknowledgedBy:  userID,
+	}
+
+	if err := s.eventRepository.AddAcknowledgement(ctx, objID, ack); err != nil {
+		return err
+	}
+
+	return s.escalationRepository.Acknowledge(ctx, objID, occ.Start, now)
+}
+
+func validateEscalation(i int, policy *EscalationPolicy) error {
+
+	if policy == nil {
+		return nil
+	}
+
+	if policy.RepeatEveryMinutes < 1 {
+		return fmt.Errorf("invalid reminder_settings[%d].escalation: repeat_every_minutes must be at least 1", i)
+	}
+
+	if policy.MaxAttempts < 1 || policy.MaxAttempts > maxEscalationAttempts {
+		return fmt.Errorf("invalid reminder_settings[%d].escalation: max_attempts must be between 1 and %d", i, maxEscalationAttempts)
+	}
+
+	return nil
+}
+
+// isAcknowledged reports whether the occurrence starting at start was
+// acknowledged.
+func (e *Event) isAcknowledged(start time.Time) bool {
+	for _, ack := range e.Acknowledgements {
+		if ack.OccurrenceStart.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// startEscalations records escalation progress for the first send of every
+// due reminder whose rule has an escalation policy. The first send counts as
+// attempt one.
+func (s *eventService) startEscalations(ctx context.Context, ev *Event, due []dueReminder) {
+
+	for _, d := range due {
+		policy := ev.Reminders[d.RuleIndex].Escalation
+		if policy == nil || d.Attempt != 0 {
+			continue
+		}
+
+		now := time.Now()
+		next := d.FireAt.Add(time.Duration(policy.RepeatEveryMinutes) * time.Minute)
+
+		esc := &Escalation{
+			ID:              primitive.NewObjectID(),
+			EventID:         ev.ID,
+			UserID:          ev.UserID,
+			OccurrenceStart: d.Occurrence.Start,
+			RuleIndex:       d.RuleIndex,
+			Attempts:        1,
+			NextAt:          &next,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+
+		if _, err := s.escalationRepository.Start(ctx, esc); err != nil {
+			log.Printf("❌ Error starting escalation for event %s: %v", ev.EventName, err)
+		}
+	}
+}
+
+// processEscalations re-sends or escalates every unacknowledged reminder
+// whose next attempt is due at now.
+func (s *eventService) processEscalations(ctx context.Context, now time.Time) {
+
+	escalations, err := s.escalationRepository.FindDue(ctx, now)
+	if err != nil {
+		log.Printf("❌ Error FindDue escalations: %v", err)
+		return
+	}
+
+	for _, esc := range escalations {
+		s.advanceEscalation(ctx, esc, now)
+	}
+}
+
+func (s *eventService) advanceEscalation(ctx context.Context, esc *Escalation, now time.Time) {
+
+	ev, err := s.eventRepository.FindEventByID(ctx, esc.EventID)
+	if err != nil {
+		log.Printf("❌ Error loading event %s for escalation: %v", esc.EventID.Hex(), err)
+		return
+	}
+
+	var policy *EscalationPolicy
+	if ev != nil && esc.RuleIndex < len(ev.Reminders) {
+		policy = ev.Reminders[esc.RuleIndex].Escalation
+	}
+
+	prevNextAt := *esc.NextAt
+	esc.UpdatedAt = now
+
+	switch {
+	case ev == nil || policy == nil || !ev.IsSend || !ev.IsShow || ev.isAcknowledged(esc.OccurrenceStart):
+		// The event, its rule or its notifications went away, or the
+		// acknowledgement raced this tick.
+		esc.NextAt = nil
+		esc.FinishedAt = &now
+		if _, err := s.escalationRepository.Update(ctx, esc, prevNextAt); err != nil {
+			log.Printf("❌ Error finishing escalation %s: %v", esc.ID.Hex(), err)
+		}
+
+	case esc.Attempts >= policy.MaxAttempts:
+		esc.NextAt = nil
+		esc.EscalatedAt = &now
+		esc.FinishedAt = &now
+		if !s.claimEscalation(ctx, esc, prevNextAt) {
+			return
+		}
+
+		if policy.BackupUserID == "" {
+			log.Printf("⚠️ Event %s unacknowledged after %d attempts, no backup user", ev.EventName, esc.Attempts)
+			return
+		}
+
+		log.Printf("🚨 Escalating event %s to backup user %s after %d attempts", ev.EventName, policy.BackupUserID, esc.Attempts)
+		s.sendNotification(ctx, ev, reminderNotice{
+			Trigger:    triggerBackup,
+			RuleIndex:  &esc.RuleIndex,
+			Channels:   ev.Reminders[esc.RuleIndex].Channels,
+			Occurrence: esc.OccurrenceStart,
+			Recipient:  policy.BackupUserID,
+			Escalated:  true,
+			Attempt:    esc.Attempts,
+		})
+
+	default:
+		esc.Attempts++
+		next := now.Add(time.Duration(policy.RepeatEveryMinutes) * time.Minute)
+		esc.NextAt = &next
+		if !s.claimEscalation(ctx, esc, prevNextAt) {
+			return
+		}
+
+		log.Printf("🔁 Re-sending unacknowledged event %s (attempt %d/%d)", ev.EventName, esc.Attempts, policy.MaxAttempts)
+		s.sendNotification(ctx, ev, reminderNotice{
+			Trigger:     triggerEscalation,
+			RuleIndex:   &esc.RuleIndex,
+			Channels:    ev.Reminders[esc.RuleIndex].Channels,
+			Occurrence:  esc.OccurrenceStart,
+			Attempt:     esc.Attempts,
+			Late:        prevNextAt.Before(now),
+			ScheduledAt: prevNextAt,
+		})
+	}
+}
+
+// claimEscalation stores the escalation's next step, fenced, and reports
+// whether this tick won the send that goes with it.
+func (s *eventService) claimEscalation(ctx context.Context, esc *Escalation, prevNextAt time.Time) bool {
+
+	var won bool
+	err := s.fenced(ctx, func(ctx context.Context) (err error) {
+		won, err = s.escalationRepository.Update(ctx, esc, prevNextAt)
+		return err
+	})
+	if err != nil {
+		log.Printf("❌ Error claiming escalation %s: %v", esc.ID.Hex(), err)
+		return false
+	}
+
+	return won
+}
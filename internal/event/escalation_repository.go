@@ -0,0 +1,136 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// finishedEscalationTTL is how long finished escalations are kept for
+// inspection before Mongo expires them.
+const finishedEscalationTTL = 30 * 24 * time.Hour
+
+type EscalationRepository interface {
+	Start(ctx context.Context, esc *Escalation) (bool, error)
+	FindDue(ctx context.Context, now time.Time) ([]*Escalation, error)
+	Update(ctx context.Context, esc *Escalation, prevNextAt time.Time) (bool, error)
+	Acknowledge(ctx context.Context, eventID primitive.ObjectID, occurrenceStart time.Time, at time.Time) error
+}
+
+type escalationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEscalationRepository(collection *mongo.Collection) (EscalationRepository, error) {
+	if err := EnsureEscalationIndexes(context.Background(), collection); err != nil {
+		return nil, err
+	}
+	return &escalationRepository{
+		collection: collection,
+	}, nil
+}
+
+// Start inserts the escalation unless one already exists for the same event,
+// occurrence and rule, and reports whether it was inserted.
+func (r *escalationRepository) Start(ctx context.Context, esc *Escalation) (bool, error) {
+
+	_, err := r.collection.InsertOne(ctx, esc)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *escalationRepository) FindDue(ctx context.Context, now time.Time) ([]*Escalation, error) {
+
+	var escalations []*Escalation
+
+	cursor, err := r.collection.Find(ctx, bson.M{"next_at": bson.M{"$lte": now}})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &escalations)
+	if err != nil {
+		return nil, err
+	}
+
+	return escalations, nil
+}
+
+// Update saves the escalation only if it is still scheduled at prevNextAt,
+// so concurrent ticks and acknowledgements never double-send. It reports
+// whether the write won.
+func (r *escalationRepository) Update(ctx context.Context, esc *Escalation, prevNextAt time.Time) (bool, error) {
+
+	res, err := r.collection.ReplaceOne(ctx,
+		bson.M{
+			"_id":     esc.ID,
+			"next_at": prevNextAt,
+		},
+		esc,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount == 1, nil
+}
+
+func (r *escalationRepository) Acknowledge(ctx context.Context, eventID primitive.ObjectID, occurrenceStart time.Time, at time.Time) error {
+
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{
+			"event_id":         eventID,
+			"occurrence_start": occurrenceStart,
+			"acknowledged_at":  bson.M{"$exists": false},
+		},
+		bson.M{
+			"$set":   bson.M{"acknowledged_at": at, "finished_at": at, "updated_at": at},
+			"$unset": bson.M{"next_at": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func EnsureEscalationIndexes(ctx context.Context, coll *mongo.Collection) error {
+
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "event_id", Value: 1},
+				{Key: "occurrence_start", Value: 1},
+				{Key: "rule_index", Value: 1},
+			},
+			Options: options.Index().
+				SetName("by_event_occurrence_rule").
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "next_at", Value: 1}},
+			Options: options.Index().
+				SetName("by_next_at").
+				SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "finished_at", Value: 1}},
+			Options: options.Index().
+				SetName("finished_ttl").
+				SetExpireAfterSeconds(int32(finishedEscalationTTL.Seconds())),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
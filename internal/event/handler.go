@@ -5,7 +5,9 @@ import (
 	"event-service/helper"
 	"event-service/pkg/constants"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -162,3 +164,247 @@ func (h *EventHandler) SendEventNotifications(c *gin.Context) {
 
 	helper.SendSuccess(c, http.StatusOK, "Send event notifications successfully", nil)
 }
+
+func (h *EventHandler) GetEventOccurrences(c *gin.Context) {
+
+	id := c.Param("id")
+
+	from := c.Query("from")
+	to := c.Query("to")
+	if from == "" || to == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("from and to are required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	occurrences, err := h.eventService.GetEventOccurrences(c, id, from, to)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get event occurrences successfully", occurrences)
+}
+
+func (h *EventHandler) GetCalendar(c *gin.Context) {
+
+	userID := c.Query("user_id")
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	from := c.Query("from")
+	to := c.Query("to")
+	if from == "" || to == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("from and to are required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	items, err := h.eventService.GetCalendar(c, userID, from, to, c.Query("time_zone"))
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get calendar successfully", items)
+}
+
+func (h *EventHandler) OverrideOccurrence(c *gin.Context) {
+
+	id := c.Param("id")
+	date := c.Param("date")
+
+	var req OccurrenceOverrideRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	err := h.eventService.OverrideOccurrence(c, id, date, &req)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Update occurrence successfully", nil)
+}
+
+func (h *EventHandler) CancelOccurrence(c *gin.Context) {
+
+	id := c.Param("id")
+	date := c.Param("date")
+
+	err := h.eventService.CancelOccurrence(c, id, date)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Cancel occurrence successfully", nil)
+}
+
+func (h *EventHandler) ExportCalendar(c *gin.Context) {
+
+	userID := c.Query("user_id")
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	data, err := h.eventService.ExportCalendar(c, userID)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="events.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
+
+func (h *EventHandler) ImportEvents(c *gin.Context) {
+
+	userID := c.PostForm("user_id")
+	if userID == "" {
+		userID = c.GetString(constants.UserID)
+	}
+
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("file is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	if file.Size > maxImportSize {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("file must not exceed %d bytes", maxImportSize), helper.ErrInvalidRequest)
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+	defer f.Close()
+
+	result, err := h.eventService.ImportEvents(c, userID, c.PostForm("time_zone"), io.LimitReader(f, maxImportSize))
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Import events successfully", result)
+}
+
+func (h *EventHandler) SnoozeEvent(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req SnoozeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	snooze, err := h.eventService.SnoozeEvent(c, id, &req)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Snooze event successfully", snooze)
+}
+
+func (h *EventHandler) AcknowledgeOccurrence(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req AcknowledgeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	err := h.eventService.AcknowledgeOccurrence(c, id, c.GetString(constants.UserID), &req)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Acknowledge occurrence successfully", nil)
+}
+
+func (h *EventHandler) GetDeliveries(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var limit int64
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			helper.SendError(c, http.StatusBadRequest, fmt.Errorf("invalid limit"), helper.ErrInvalidRequest)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.eventService.GetDeliveries(c, id, limit)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get deliveries successfully", deliveries)
+}
+
+func (h *EventHandler) GetRetries(c *gin.Context) {
+
+	var limit int64
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			helper.SendError(c, http.StatusBadRequest, fmt.Errorf("invalid limit"), helper.ErrInvalidRequest)
+			return
+		}
+		limit = n
+	}
+
+	retries, err := h.eventService.GetRetries(c, c.Query("status"), limit)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get retries successfully", retries)
+}
+
+func (h *EventHandler) ReplayRetry(c *gin.Context) {
+
+	id := c.Param("id")
+
+	err := h.eventService.ReplayRetry(c, id)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Replay retry successfully", nil)
+}
+
+func (h *EventHandler) ReplayDeadRetries(c *gin.Context) {
+
+	count, err := h.eventService.ReplayDeadRetries(c)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Replay dead retries successfully", &ReplayRetriesResponse{Replayed: count})
+}
@@ -0,0 +1,290 @@
+package event
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"event-service/pkg/ical"
+)
+
+const icalProdID = "-//SenBox//Event Service//EN"
+
+// vtimezoneSpan caps how far past an event's start the exported VTIMEZONE
+// lists UTC offset transitions.
+const vtimezoneSpan = 10 * 366 * 24 * time.Hour
+
+// buildCalendar maps events onto a VCALENDAR with one VEVENT per series, one
+// per overridden occurrence, and the VTIMEZONEs their TZIDs refer to.
+func (s *eventService) buildCalendar(events []*Event) *ical.Component {
+
+	cal := ical.NewComponent("VCALENDAR")
+	cal.AddProperty("VERSION", "2.0")
+	cal.AddProperty("PRODID", icalProdID)
+	cal.AddProperty("CALSCALE", "GREGORIAN")
+	cal.AddProperty("METHOD", "PUBLISH")
+	// Polling hints for subscribed feeds; downloads ignore them.
+	cal.AddProperty("REFRESH-INTERVAL", "PT1H", ical.Param{Name: "VALUE", Value: "DURATION"})
+	cal.AddProperty("X-PUBLISHED-TTL", "PT1H")
+
+	type zoneRange struct {
+		loc      *time.Location
+		from, to time.Time
+	}
+
+	var zones []*zoneRange
+	zoneIndex := map[string]*zoneRange{}
+	var vevents []*ical.Component
+
+	for _, ev := range events {
+		components, err := s.eventComponents(ev)
+		if err != nil {
+			log.Printf("⛔ Skipping event %s in ics export: %v", ev.ID.Hex(), err)
+			continue
+		}
+
+		if len(components) == 0 {
+			continue
+		}
+
+		vevents = append(vevents, components...)
+
+		loc := s.eventLocation(ev)
+		from := ev.StartDate
+		to := ev.EndDate
+		if to.Sub(from) > vtimezoneSpan {
+			to = from.Add(vtimezoneSpan)
+		}
+
+		zr, ok := zoneIndex[loc.String()]
+		if !ok {
+			zr = &zoneRange{loc: loc, from: from, to: to}
+			zoneIndex[loc.String()] = zr
+			zones = append(zones, zr)
+			continue
+		}
+		if from.Before(zr.from) {
+			zr.from = from
+		}
+		if to.After(zr.to) {
+			zr.to = to
+		}
+	}
+
+	for _, zr := range zones {
+		cal.AddComponent(ical.Timezone(zr.loc, zr.from, zr.to))
+	}
+
+	for _, vevent := range vevents {
+		cal.AddComponent(vevent)
+	}
+
+	return cal
+}
+
+// eventComponents returns the VEVENT for the series followed by one VEVENT
+// per overridden occurrence, sharing the series UID.
+func (s *eventService) eventComponents(ev *Event) ([]*ical.Component, error) {
+
+	loc := s.eventLocation(ev)
+
+	rec, err := ev.recurrence(loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rrule: %w", err)
+	}
+
+	if rec == nil {
+		return nil, nil
+	}
+
+	start := ev.StartDate.In(loc)
+	end := ev.EndDate.In(loc)
+
+	// RFC 5545 always counts DTSTART as the first instance, so anchor the
+	// export on the first instance the rule actually produces.
+	instances := rec.Between(start, start, end)
+	if len(instances) == 0 {
+		return nil, nil
+	}
+	first := instances[0]
+
+	uid := ev.ID.Hex() + "@event-service"
+
+	series := s.newVEvent(ev, uid, s.newOccurrence(ev, first, nil))
+
+	if len(instances) > 1 {
+		bounded := boundedRecurrence(rec, end, len(instances))
+		if ev.AllDay {
+			series.AddProperty("RRULE", bounded.DateString(loc))
+		} else {
+			series.AddProperty("RRULE", bounded.String())
+		}
+	}
+
+	components := []*ical.Component{series}
+
+	for i := range ev.Exceptions {
+		exc := &ev.Exceptions[i]
+		original := exc.OriginalStart.In(loc)
+
+		if exc.Cancelled {
+			value, param := icalInstant(ev, original, loc)
+			series.AddProperty("EXDATE", value, param)
+			continue
+		}
+
+		if valid, err := s.seriesStarts(ev, original, original); err != nil || len(valid) == 0 {
+			continue
+		}
+
+		override := s.newVEvent(ev, uid, s.newOccurrence(ev, original, exc))
+		value, param := icalInstant(ev, original, loc)
+		override.AddProperty("RECURRENCE-ID", value, param)
+		components = append(components, override)
+	}
+
+	return components, nil
+}
+
+func (s *eventService) newVEvent(ev *Event, uid string, occ *OccurrenceResponse) *ical.Component {
+
+	loc := s.eventLocation(ev)
+
+	stamp := ev.UpdatedAt
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	vevent := ical.NewComponent("VEVENT")
+	vevent.AddProperty("UID", uid)
+	vevent.AddProperty("DTSTAMP", stamp.UTC().Format(ical.DateTimeUTCLayout))
+	if !ev.CreatedAt.IsZero() {
+		vevent.AddProperty("CREATED", ev.CreatedAt.UTC().Format(ical.DateTimeUTCLayout))
+	}
+	vevent.AddProperty("LAST-MODIFIED", stamp.UTC().Format(ical.DateTimeUTCLayout))
+	vevent.AddProperty("SUMMARY", ical.EscapeText(occ.EventName))
+	if occ.Note != "" {
+		vevent.AddProperty("DESCRIPTION", ical.EscapeText(occ.Note))
+	}
+	if ev.Url != "" {
+		vevent.AddProperty("URL", ev.Url, ical.Param{Name: "VALUE", Value: "URI"})
+	}
+	value, param := icalInstant(ev, occ.Start, loc)
+	vevent.AddProperty("DTSTART", value, param)
+	if occ.End.After(occ.Start) {
+		// For all-day events this is the exclusive end date, as RFC 5545 expects.
+		value, param = icalInstant(ev, occ.End, loc)
+		vevent.AddProperty("DTEND", value, param)
+	}
+
+	if ev.IsSend {
+		for i, rule := range ev.Reminders {
+			if !rule.Enable {
+				continue
+			}
+			vevent.AddComponent(newVAlarm(s.alarmDescription(ev, occ, i), rule, ev.AllDay))
+		}
+	}
+
+	return vevent
+}
+
+// icalInstant formats t as a DATE for all-day events and as a TZID-qualified
+// local DATE-TIME otherwise.
+func icalInstant(ev *Event, t time.Time, loc *time.Location) (string, ical.Param) {
+	if ev.AllDay {
+		return t.In(loc).Format(ical.DateLayout), ical.Param{Name: "VALUE", Value: "DATE"}
+	}
+	return t.In(loc).Format(ical.DateTimeLayout), ical.Param{Name: "TZID", Value: loc.String()}
+}
+
+func newVAlarm(description string, rule ReminderRule, allDay bool) *ical.Component {
+
+	alarm := ical.NewComponent("VALARM")
+	alarm.AddProperty("ACTION", "DISPLAY")
+	alarm.AddProperty("DESCRIPTION", ical.EscapeText(description))
+	alarm.AddProperty("TRIGGER", reminderTrigger(rule, allDay))
+
+	return alarm
+}
+
+// reminderTrigger renders a rule's offset as an iCalendar duration relative to
+// DTSTART. Day-based rules of all-day events are relative to local midnight
+// and land on the rule's time of day, e.g. "1 day before at 08:00" is -PT16H.
+func reminderTrigger(rule ReminderRule, allDay bool) string {
+
+	n := rule.RemiderCount
+	if n < 0 {
+		n = 0
+	}
+
+	if allDay && isDayBasedUnit(rule.ReminderBefore) {
+		days := n
+		switch rule.ReminderBefore {
+		case "weeks":
+			days = n * 7
+		case "months":
+			days = n * 30
+		}
+		hh, mm := rule.atClock()
+		return formatTriggerMinutes(-days*24*60 + int64(hh*60+mm))
+	}
+
+	switch rule.ReminderBefore {
+	case "hours":
+		return fmt.Sprintf("-PT%dH", n)
+	case "days":
+		return fmt.Sprintf("-P%dD", n)
+	case "weeks":
+		return fmt.Sprintf("-P%dW", n)
+	case "months":
+		// iCalendar durations have no month unit.
+		return fmt.Sprintf("-P%dD", n*30)
+	default:
+		return fmt.Sprintf("-PT%dM", n)
+	}
+}
+
+func formatTriggerMinutes(minutes int64) string {
+
+	sign := ""
+	if minutes < 0 {
+		sign = "-"
+		minutes = -minutes
+	}
+
+	if minutes%(24*60) == 0 {
+		return fmt.Sprintf("%sP%dD", sign, minutes/(24*60))
+	}
+
+	value := sign + "PT"
+	if h := minutes / 60; h > 0 {
+		value += fmt.Sprintf("%dH", h)
+	}
+	if m := minutes % 60; m > 0 {
+		value += fmt.Sprintf("%dM", m)
+	}
+
+	return value
+}
+
+// boundedRecurrence folds the event's end_date into the rule, since clients
+// only see the RRULE. instances is how many occurrences fall before end.
+func boundedRecurrence(rec *Recurrence, end time.Time, instances int) *Recurrence {
+
+	bounded := *rec
+
+	if bounded.Count > 0 {
+		if instances < bounded.Count {
+			bounded.Count = 0
+			bounded.Until = end.UTC()
+		}
+		return &bounded
+	}
+
+	if bounded.Until.IsZero() || end.Before(bounded.Until) {
+		bounded.Until = end.UTC()
+	}
+
+	return &bounded
+}
@@ -0,0 +1,486 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"event-service/pkg/ical"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxImportSize bounds an uploaded .ics file.
+const maxImportSize = 5 << 20
+
+// importHorizonYears bounds open-ended imported rules, since every event needs
+// an end_date.
+const importHorizonYears = 5
+
+type importedSeries struct {
+	event *Event
+	items []*ImportItemResponse
+}
+
+func (s *eventService) ImportEvents(ctx context.Context, userID string, timeZone string, r io.Reader) (*ImportResultResponse, error) {
+
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+
+	defaultLoc, err := s.resolveLocation(timeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	cal, err := ical.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ics file: %w", err)
+	}
+
+	if cal.Name != "VCALENDAR" {
+		return nil, errors.New("invalid ics file: missing VCALENDAR")
+	}
+
+	result := &ImportResultResponse{Items: make([]*ImportItemResponse, 0)}
+
+	var series []*importedSeries
+	byUID := map[string]*importedSeries{}
+
+	type pendingOverride struct {
+		vevent *ical.Component
+		item   *ImportItemResponse
+	}
+	var overrides []pendingOverride
+
+	for i, vevent := range cal.ComponentsNamed("VEVENT") {
+
+		item := &ImportItemResponse{
+			Index:   i,
+			UID:     propertyValue(vevent, "UID"),
+			Summary: ical.UnescapeText(propertyValue(vevent, "SUMMARY")),
+		}
+		result.Items = append(result.Items, item)
+
+		if vevent.Property("RECURRENCE-ID") != nil {
+			overrides = append(overrides, pendingOverride{vevent: vevent, item: item})
+			continue
+		}
+
+		ev, warnings, err := s.eventFromVEvent(userID, vevent, defaultLoc)
+		item.Warnings = warnings
+		if err != nil {
+			item.Error = err.Error()
+			continue
+		}
+
+		item.EventID = ev.ID.Hex()
+		item.Success = true
+
+		is := &importedSeries{event: ev, items: []*ImportItemResponse{item}}
+		series = append(series, is)
+		if item.UID != "" {
+			byUID[item.UID] = is
+		}
+	}
+
+	for _, o := range overrides {
+		is, ok := byUID[o.item.UID]
+		if !ok {
+			o.item.Error = "no recurring event with this UID in the file"
+			continue
+		}
+
+		warnings, err := s.applyVEventOverride(is.event, o.vevent, defaultLoc)
+		o.item.Warnings = warnings
+		if err != nil {
+			o.item.Error = err.Error()
+			continue
+		}
+
+		o.item.EventID = is.event.ID.Hex()
+		o.item.Success = true
+		is.items = append(is.items, o.item)
+	}
+
+	for _, is := range series {
+		is.event.setNextFireAt(s.nextFireAt(is.event, time.Now()))
+		if err := s.eventRepository.Create(ctx, is.event); err != nil {
+			for _, item := range is.items {
+				item.Success = false
+				item.EventID = ""
+				item.Error = err.Error()
+			}
+		}
+	}
+
+	for _, item := range result.Items {
+		if item.Success {
+			result.Imported++
+		} else {
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
+
+// eventFromVEvent maps a master VEVENT onto a new Event. Warnings describe
+// parts of the component that could not be carried over.
+func (s *eventService) eventFromVEvent(userID string, vevent *ical.Component, defaultLoc *time.Location) (*Event, []string, error) {
+
+	var warnings []string
+
+	summary := ical.UnescapeText(propertyValue(vevent, "SUMMARY"))
+	if summary == "" {
+		return nil, nil, errors.New("SUMMARY is required")
+	}
+
+	if strings.EqualFold(propertyValue(vevent, "STATUS"), "CANCELLED") {
+		return nil, nil, errors.New("event is cancelled")
+	}
+
+	dtstart := vevent.Property("DTSTART")
+	if dtstart == nil {
+		return nil, nil, errors.New("DTSTART is required")
+	}
+
+	loc, warning := s.importLocation(dtstart, defaultLoc)
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	start, dateOnly, err := ical.ParseDateTime(dtstart.Value, loc)
+	if err != nil {
+		return nil, warnings, fmt.Errorf("invalid DTSTART: %w", err)
+	}
+	start = start.In(loc).Truncate(time.Second)
+
+	duration, err := s.vEventDuration(vevent, start, dateOnly, defaultLoc)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	days := allDayDays(duration)
+
+	var rec *Recurrence
+	var end time.Time
+
+	if rrule := vevent.Property("RRULE"); rrule != nil {
+		rec, err = ParseRecurrence(rrule.Value, loc)
+		if err != nil {
+			return nil, warnings, fmt.Errorf("invalid RRULE: %w", err)
+		}
+
+		switch {
+		case !rec.Until.IsZero():
+			end = rec.Until
+		default:
+			horizon := start.AddDate(importHorizonYears, 0, 0)
+			instances := rec.Between(start, start, horizon)
+			if len(instances) == 0 {
+				return nil, warnings, errors.New("RRULE produces no occurrences")
+			}
+			end = instances[len(instances)-1]
+			if rec.Count == 0 || len(instances) < rec.Count {
+				warnings = append(warnings, fmt.Sprintf("recurrence limited to %d years", importHorizonYears))
+			}
+		}
+
+		if end.Before(start) {
+			return nil, warnings, errors.New("RRULE ends before DTSTART")
+		}
+	} else if !dateOnly {
+		rec = &Recurrence{Freq: FreqDaily, Interval: 1, Count: 1, WeekStart: time.Monday}
+		end = start
+	} else {
+		// A single all-day event spans start_date..end_date without a rule.
+		end = start.AddDate(0, 0, int(days-1))
+	}
+
+	if dateOnly {
+		end = startOfDay(end.In(loc)).AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	if vevent.Property("RDATE") != nil {
+		warnings = append(warnings, "RDATE is not supported and was ignored")
+	}
+
+	link := propertyValue(vevent, "URL")
+	if validateURL(link) != nil {
+		warnings = append(warnings, "URL is not an http or https link and was ignored")
+		link = ""
+	}
+
+	ev := &Event{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		EventName: summary,
+		StartDate: start,
+		EndDate:   end.In(loc),
+		IsShow:    true,
+		IsSend:    true,
+		Note:      ical.UnescapeText(propertyValue(vevent, "DESCRIPTION")),
+		Url:       link,
+		TimeZone:  loc.String(),
+		AllDay:    dateOnly,
+		// Expiration is the number of one-minute send attempts; zero never fires.
+		Schedule:  ScheduleSettings{Expiration: 1},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if rec != nil {
+		ev.RRule = rec.String()
+	}
+
+	if dateOnly {
+		if rec != nil {
+			ev.DurationDays = days
+		}
+	} else {
+		ev.DurationMinutes = int64(duration / time.Minute)
+	}
+
+	for _, exdate := range vevent.PropertiesNamed("EXDATE") {
+		exLoc, _ := s.importLocation(exdate, defaultLoc)
+		times, err := ical.ParseDateTimeList(exdate.Value, exLoc)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid EXDATE ignored: %v", err))
+			continue
+		}
+		for _, t := range times {
+			ev.setException(OccurrenceException{OriginalStart: t.In(loc), Cancelled: true})
+		}
+	}
+
+	for _, alarm := range vevent.ComponentsNamed("VALARM") {
+		rule, err := reminderFromVAlarm(alarm, summary, dateOnly)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("VALARM ignored: %v", err))
+			continue
+		}
+		if rule.Message != nil {
+			if _, err := parseMessageTemplate(*rule.Message); err != nil {
+				warnings = append(warnings, fmt.Sprintf("VALARM DESCRIPTION ignored: %v", err))
+				rule.Message = nil
+			}
+		}
+		ev.Reminders = append(ev.Reminders, rule)
+	}
+
+	if err := s.validateReminders(ev.Reminders); err != nil {
+		return nil, warnings, err
+	}
+
+	return ev, warnings, nil
+}
+
+// applyVEventOverride records a VEVENT carrying a RECURRENCE-ID as an
+// exception of its master event.
+func (s *eventService) applyVEventOverride(ev *Event, vevent *ical.Component, defaultLoc *time.Location) ([]string, error) {
+
+	var warnings []string
+
+	loc := s.eventLocation(ev)
+
+	recurrenceID := vevent.Property("RECURRENCE-ID")
+	recLoc, warning := s.importLocation(recurrenceID, defaultLoc)
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	original, _, err := ical.ParseDateTime(recurrenceID.Value, recLoc)
+	if err != nil {
+		return warnings, fmt.Errorf("invalid RECURRENCE-ID: %w", err)
+	}
+	original = original.In(loc)
+
+	if valid, err := s.seriesStarts(ev, original, original); err != nil || len(valid) == 0 {
+		return warnings, errors.New("RECURRENCE-ID does not match an occurrence")
+	}
+
+	if strings.EqualFold(propertyValue(vevent, "STATUS"), "CANCELLED") {
+		ev.setException(OccurrenceException{OriginalStart: original, Cancelled: true})
+		return warnings, nil
+	}
+
+	exc := OccurrenceException{OriginalStart: original}
+	start := original
+
+	if dtstart := vevent.Property("DTSTART"); dtstart != nil {
+		startLoc, _ := s.importLocation(dtstart, defaultLoc)
+		t, dateOnly, err := ical.ParseDateTime(dtstart.Value, startLoc)
+		if err != nil {
+			return warnings, fmt.Errorf("invalid DTSTART: %w", err)
+		}
+		t = t.In(loc).Truncate(time.Second)
+		if t.Before(ev.StartDate) || t.After(ev.EndDate) {
+			return warnings, errors.New("DTSTART must be within the recurring event's range")
+		}
+		if !t.Equal(original) {
+			exc.StartDate = &t
+		}
+		start = t
+
+		duration, err := s.vEventDuration(vevent, start, dateOnly, defaultLoc)
+		if err != nil {
+			return warnings, err
+		}
+		if minutes := int64(duration / time.Minute); !ev.AllDay && minutes != ev.DurationMinutes {
+			exc.DurationMinutes = &minutes
+		}
+	}
+
+	if summary := ical.UnescapeText(propertyValue(vevent, "SUMMARY")); summary != "" && summary != ev.EventName {
+		exc.EventName = &summary
+	}
+
+	if note := ical.UnescapeText(propertyValue(vevent, "DESCRIPTION")); note != "" && note != ev.Note {
+		exc.Note = &note
+	}
+
+	ev.setException(exc)
+
+	return warnings, nil
+}
+
+// vEventDuration reads DTEND or DURATION; a DATE start without either lasts
+// one day, a DATE-TIME start without either is instantaneous.
+func (s *eventService) vEventDuration(vevent *ical.Component, start time.Time, dateOnly bool, defaultLoc *time.Location) (time.Duration, error) {
+
+	if dtend := vevent.Property("DTEND"); dtend != nil {
+		endLoc, _ := s.importLocation(dtend, defaultLoc)
+		end, _, err := ical.ParseDateTime(dtend.Value, endLoc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid DTEND: %w", err)
+		}
+		if end.Before(start) {
+			return 0, errors.New("DTEND must not be before DTSTART")
+		}
+		return end.Sub(start), nil
+	}
+
+	if duration := vevent.Property("DURATION"); duration != nil {
+		d, err := ical.ParseDuration(duration.Value)
+		if err != nil {
+			return 0, err
+		}
+		if d < 0 {
+			return 0, errors.New("DURATION must not be negative")
+		}
+		return d, nil
+	}
+
+	if dateOnly {
+		return 24 * time.Hour, nil
+	}
+
+	return 0, nil
+}
+
+// importLocation resolves a property's TZID. Floating and UTC values, and
+// TZIDs outside the IANA database (e.g. Windows zone names), use defaultLoc.
+func (s *eventService) importLocation(prop *ical.Property, defaultLoc *time.Location) (*time.Location, string) {
+
+	tzid := strings.TrimPrefix(prop.Param("TZID"), "/")
+	if tzid == "" {
+		return defaultLoc, ""
+	}
+
+	loc, err := loadLocation(tzid)
+	if err != nil || tzid == "Local" {
+		return defaultLoc, fmt.Sprintf("unknown TZID %q, using %s", tzid, defaultLoc.String())
+	}
+
+	return loc, ""
+}
+
+// allDayDays converts an all-day duration to whole days, at least one.
+func allDayDays(d time.Duration) int64 {
+	days := int64((d + 12*time.Hour) / (24 * time.Hour))
+	if days < 1 {
+		return 1
+	}
+	return days
+}
+
+// reminderFromVAlarm maps a VALARM triggered before the start of the event
+// onto a ReminderRule, using the largest unit that divides the offset. For
+// all-day events the trigger is relative to local midnight and becomes a
+// number of days before plus a time of day.
+func reminderFromVAlarm(alarm *ical.Component, summary string, allDay bool) (ReminderRule, error) {
+
+	trigger := alarm.Property("TRIGGER")
+	if trigger == nil {
+		return ReminderRule{}, errors.New("TRIGGER is required")
+	}
+
+	if strings.EqualFold(trigger.Param("VALUE"), "DATE-TIME") {
+		return ReminderRule{}, errors.New("absolute triggers are not supported")
+	}
+
+	if strings.EqualFold(trigger.Param("RELATED"), "END") {
+		return ReminderRule{}, errors.New("triggers related to the end are not supported")
+	}
+
+	d, err := ical.ParseDuration(trigger.Value)
+	if err != nil {
+		return ReminderRule{}, err
+	}
+
+	rule := ReminderRule{Enable: true}
+
+	if description := ical.UnescapeText(propertyValue(alarm, "DESCRIPTION")); description != "" && description != summary {
+		rule.Message = &description
+	}
+
+	if allDay {
+		if d >= 24*time.Hour {
+			return ReminderRule{}, errors.New("triggers after the day of the event are not supported")
+		}
+		days := int64(0)
+		for d < 0 {
+			d += 24 * time.Hour
+			days++
+		}
+		at := fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+		rule.RemiderCount = days
+		rule.ReminderBefore = "days"
+		rule.At = &at
+		return rule, nil
+	}
+
+	if d > 0 {
+		return ReminderRule{}, errors.New("triggers after the start are not supported")
+	}
+
+	before := -d
+
+	switch {
+	case before > 0 && before%(7*24*time.Hour) == 0:
+		rule.RemiderCount = int64(before / (7 * 24 * time.Hour))
+		rule.ReminderBefore = "weeks"
+	case before > 0 && before%(24*time.Hour) == 0:
+		rule.RemiderCount = int64(before / (24 * time.Hour))
+		rule.ReminderBefore = "days"
+	case before > 0 && before%time.Hour == 0:
+		rule.RemiderCount = int64(before / time.Hour)
+		rule.ReminderBefore = "hours"
+	default:
+		rule.RemiderCount = int64(before / time.Minute)
+		rule.ReminderBefore = "minutes"
+	}
+
+	return rule, nil
+}
+
+func propertyValue(c *ical.Component, name string) string {
+	if p := c.Property(name); p != nil {
+		return p.Value
+	}
+	return ""
+}
@@ -0,0 +1,310 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode/utf8"
+
+	"event-service/internal/notifier"
+	"event-service/internal/user"
+)
+
+// maxMessageLength bounds a reminder's custom message template.
+const maxMessageLength = 500
+
+// profileTTL is how long a recipient's profile is reused for rendering
+// before it is fetched from the main service again.
+const profileTTL = 15 * time.Minute
+
+// sampleMessageData is what custom messages are test-rendered with on save.
+var sampleMessageData = &messageData{
+	Name:          "Event",
+	Note:          "Note",
+	LocalStart:    "08:00 01/01/2025",
+	TimeRemaining: "sau 15 phút",
+	FullName:      "User",
+}
+
+// messageData is what notification templates can reference.
+type messageData struct {
+	Name          string
+	Note          string
+	LocalStart    string
+	TimeRemaining string
+	FullName      string
+	Attempt       int
+}
+
+// messageFields are the placeholders a custom message may use.
+var messageFields = []string{"Name", "Note", "LocalStart", "TimeRemaining", "FullName", "Attempt"}
+
+// maxRenderedLength bounds a rendered notification body.
+const maxRenderedLength = 2000
+
+// parseMessageTemplate parses a rule's custom message and renders it once
+// against sample data, so mistakes are rejected when the event is saved
+// rather than when the reminder fires. Messages may only be text and
+// {{.Field}} placeholders: actions such as range could keep a send busy
+// for as long as they like.
+func parseMessageTemplate(message string) (*template.Template, error) {
+
+	if len(message) > maxMessageLength {
+		return nil, fmt.Errorf("must be at most %d characters", maxMessageLength)
+	}
+
+	tmpl, err := template.New("message").Parse(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tmpl.Templates()) > 1 {
+		return nil, errors.New("only {{.Field}} placeholders are allowed")
+	}
+	if tmpl.Tree != nil {
+		if err := checkPlaceholders(tmpl.Tree.Root); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tmpl.Execute(&strings.Builder{}, sampleMessageData); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// checkPlaceholders accepts text and actions printing a single field of
+// messageFields, and nothing else.
+func checkPlaceholders(node parse.Node) error {
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			if err := checkPlaceholders(child); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *parse.TextNode:
+		return nil
+
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 && len(n.Pipe.Cmds) == 1 && len(n.Pipe.Cmds[0].Args) == 1 {
+			if field, ok := n.Pipe.Cmds[0].Args[0].(*parse.FieldNode); ok && len(field.Ident) == 1 {
+				if slices.Contains(messageFields, field.Ident[0]) {
+					return nil
+				}
+				return fmt.Errorf("unknown field %s: must be one of %s", field.Ident[0], strings.Join(messageFields, ", "))
+			}
+		}
+	}
+
+	return errors.New("only {{.Field}} placeholders are allowed")
+}
+
+// renderedMessage is the text of a notification in one locale.
+type renderedMessage struct {
+	Title      string
+	Body       string
+	LocalStart string
+	Labels     notifier.Labels
+}
+
+// renderMessage renders a notification in the event's locale, else the
+// recipient's, else the default one. A rule's Message replaces the body of
+// its reminders.
+func (s *eventService) renderMessage(ctx context.Context, ev *Event, notice reminderNotice, recipient string, now time.Time) *renderedMessage {
+
+	profile := s.profiles.get(ctx, s.userService, recipient, now)
+
+	locale := ev.Locale
+	if locale == "" && profile != nil {
+		locale = profile.Locale
+	}
+	locale = s.resolveLocale(locale)
+	cat := catalogs[locale]
+
+	body := cat.Body
+	if notice.Escalated {
+		body = cat.Escalation
+	} else if rule := noticeRule(ev, notice); rule != nil && rule.Message != nil && *rule.Message != "" {
+		tmpl, err := parseMessageTemplate(*rule.Message)
+		if err != nil {
+			log.Printf("⛔ Invalid message template for event %s: %v", ev.EventName, err)
+		} else {
+			body = tmpl
+		}
+	}
+
+	data := s.messageData(ev, notice, cat, now)
+	if profile != nil {
+		data.FullName = profile.FullName
+	}
+
+	title, err := executeTemplate(cat.Title, data)
+	if err != nil {
+		title = "🔔 " + ev.EventName
+	}
+
+	text, err := executeTemplate(body, data)
+	if err != nil {
+		log.Printf("⛔ Error rendering message for event %s: %v", ev.EventName, err)
+		text, _ = executeTemplate(cat.Body, data)
+	}
+
+	return &renderedMessage{
+		Title:      title,
+		Body:       text,
+		LocalStart: data.LocalStart,
+		Labels:     cat.Labels,
+	}
+}
+
+// alarmDescription renders the message of the rule at index for the
+// occurrence as it reads when the reminder fires, since calendar clients
+// show VALARM text as is. It falls back to the event name when the rule has
+// no message or it cannot be rendered.
+func (s *eventService) alarmDescription(ev *Event, occ *OccurrenceResponse, index int) string {
+
+	rule := ev.Reminders[index]
+	if rule.Message == nil || *rule.Message == "" {
+		return occ.EventName
+	}
+
+	tmpl, err := parseMessageTemplate(*rule.Message)
+	if err != nil {
+		return occ.EventName
+	}
+
+	shown := *ev
+	shown.EventName = occ.EventName
+	shown.Note = occ.Note
+
+	fire := s.reminderFireTime(ev, occ.Start, rule)
+	data := s.messageData(&shown, reminderNotice{Occurrence: occ.Start}, s.catalog(ev.Locale), fire)
+
+	text, err := executeTemplate(tmpl, data)
+	if err != nil || text == "" {
+		return occ.EventName
+	}
+
+	return text
+}
+
+// catalog returns the catalog of locale, falling back to the default.
+func (s *eventService) catalog(locale string) *catalog {
+	return catalogs[s.resolveLocale(locale)]
+}
+
+// resolveLocale returns the supported language code notifications for
+// locale are rendered in.
+func (s *eventService) resolveLocale(locale string) string {
+	if l := normalizeLocale(locale); catalogs[l] != nil {
+		return l
+	}
+	if catalogs[s.defaultLocale] != nil {
+		return s.defaultLocale
+	}
+	return fallbackLocale
+}
+
+func (s *eventService) messageData(ev *Event, notice reminderNotice, cat *catalog, now time.Time) *messageData {
+
+	start := notice.Occurrence
+	if start.IsZero() {
+		start = ev.StartDate
+	}
+
+	local := start.In(s.eventLocation(ev))
+
+	data := &messageData{
+		Name:          ev.EventName,
+		Note:          ev.Note,
+		TimeRemaining: cat.relative(start.Sub(now)),
+		Attempt:       notice.Attempt,
+	}
+
+	if ev.AllDay {
+		data.LocalStart = local.Format(cat.DateFormat)
+	} else {
+		data.LocalStart = local.Format(cat.DateTimeFormat)
+	}
+
+	return data
+}
+
+// noticeRule returns the reminder rule a notice was sent for, if any.
+func noticeRule(ev *Event, notice reminderNotice) *ReminderRule {
+	if notice.RuleIndex == nil || *notice.RuleIndex < 0 || *notice.RuleIndex >= len(ev.Reminders) {
+		return nil
+	}
+	return &ev.Reminders[*notice.RuleIndex]
+}
+
+// executeTemplate renders tmpl, cut to maxRenderedLength runes.
+func executeTemplate(tmpl *template.Template, data *messageData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	text := b.String()
+	if utf8.RuneCountInString(text) > maxRenderedLength {
+		text = string([]rune(text)[:maxRenderedLength])
+	}
+	return text, nil
+}
+
+type cachedProfile struct {
+	info      *user.UserInfor
+	fetchedAt time.Time
+}
+
+// profileCache keeps recipients' profiles for profileTTL, so a minute in
+// which many reminders fire does not call the main service per send.
+type profileCache struct {
+	mu       sync.Mutex
+	profiles map[string]cachedProfile
+}
+
+func newProfileCache() *profileCache {
+	return &profileCache{profiles: make(map[string]cachedProfile)}
+}
+
+// get returns the user's profile, or nil when it cannot be fetched.
+func (c *profileCache) get(ctx context.Context, us user.UserService, userID string, now time.Time) *user.UserInfor {
+
+	c.mu.Lock()
+	cached, ok := c.profiles[userID]
+	c.mu.Unlock()
+
+	if ok && now.Sub(cached.fetchedAt) < profileTTL {
+		return cached.info
+	}
+
+	info, err := us.GetUserInfor(ctx, userID)
+	if err != nil {
+		log.Printf("❌ GetUserInfor error for user %s: %v", userID, err)
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, p := range c.profiles {
+		if now.Sub(p.fetchedAt) >= profileTTL {
+			delete(c.profiles, id)
+		}
+	}
+	c.profiles[userID] = cachedProfile{info: info, fetchedAt: now}
+
+	return info
+}
@@ -0,0 +1,32 @@
+package event
+
+import "testing"
+
+func TestParseMessageTemplate(t *testing.T) {
+
+	tests := []struct {
+		message string
+		wantErr bool
+	}{
+		{message: "Plain text"},
+		{message: "{{.Name}} starts {{.TimeRemaining}} ({{.LocalStart}})"},
+		{message: "Hi {{.FullName}}, attempt {{.Attempt}}: {{.Note}}"},
+		{message: "{{.Unknown}}", wantErr: true},
+		{message: "{{.Name.Length}}", wantErr: true},
+		{message: "{{range 300000000}}{{end}}", wantErr: true},
+		{message: "{{if .Note}}{{.Note}}{{end}}", wantErr: true},
+		{message: "{{with .Name}}{{.}}{{end}}", wantErr: true},
+		{message: `{{define "x"}}{{.Name}}{{end}}`, wantErr: true},
+		{message: `{{template "message" .}}`, wantErr: true},
+		{message: "{{$x := .Name}}", wantErr: true},
+		{message: "{{printf \"%s\" .Name}}", wantErr: true},
+		{message: "{{.Name | len}}", wantErr: true},
+		{message: "{{.Name", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseMessageTemplate(tt.message); (err != nil) != tt.wantErr {
+			t.Errorf("parseMessageTemplate(%q) = %v, wantErr %v", tt.message, err, tt.wantErr)
+		}
+	}
+}
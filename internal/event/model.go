@@ -3,26 +3,41 @@ package event
 import (
 	"time"
 
+	"event-service/internal/notifier"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Event struct {
-	ID               primitive.ObjectID `bson:"_id" json:"id"`
-	UserID           string             `bson:"user_id" json:"user_id"`
-	EventName        string             `bson:"event_name" json:"event_name"`
-	StartDate        time.Time          `bson:"start_date" json:"start_date"`
-	EndDate          time.Time          `bson:"end_date" json:"end_date"`
-	IsShow           bool               `bson:"is_show" json:"is_show"`
-	IsSend           bool               `bson:"is_send" json:"is_send"`
-	SoundKey         string             `bson:"sound_key" json:"sound_key"`
-	SoundRepeatTimes int64              `bson:"sound_repeat_times" json:"sound_repeat_times"`
-	Icon             string             `bson:"icon" json:"icon"`
-	Note             string             `bson:"note" json:"note"`
-	Url              string             `bson:"url" json:"url"`
-	Reminders        []ReminderRule     `bson:"reminder_settings" json:"reminder_settings"`
-	Schedule         ScheduleSettings   `bson:"scheduled_settings" json:"scheduled_settings"`
-	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`
+	ID               primitive.ObjectID    `bson:"_id" json:"id"`
+	UserID           string                `bson:"user_id" json:"user_id"`
+	EventName        string                `bson:"event_name" json:"event_name"`
+	StartDate        time.Time             `bson:"start_date" json:"start_date"`
+	EndDate          time.Time             `bson:"end_date" json:"end_date"`
+	IsShow           bool                  `bson:"is_show" json:"is_show"`
+	IsSend           bool                  `bson:"is_send" json:"is_send"`
+	SoundKey         string                `bson:"sound_key" json:"sound_key"`
+	SoundRepeatTimes int64                 `bson:"sound_repeat_times" json:"sound_repeat_times"`
+	Icon             string                `bson:"icon" json:"icon"`
+	Note             string                `bson:"note" json:"note"`
+	Url              string                `bson:"url" json:"url"`
+	Reminders        []ReminderRule        `bson:"reminder_settings" json:"reminder_settings"`
+	Schedule         ScheduleSettings      `bson:"scheduled_settings" json:"scheduled_settings"`
+	RRule            string                `bson:"rrule,omitempty" json:"rrule,omitempty"`
+	DurationMinutes  int64                 `bson:"duration_minutes" json:"duration_minutes"`
+	TimeZone         string                `bson:"time_zone,omitempty" json:"time_zone,omitempty"`
+	Locale           string                `bson:"locale,omitempty" json:"locale,omitempty"`
+	Exceptions       []OccurrenceException `bson:"exceptions,omitempty" json:"exceptions,omitempty"`
+	AllDay           bool                  `bson:"all_day" json:"all_day"`
+	DurationDays     int64                 `bson:"duration_days,omitempty" json:"duration_days,omitempty"`
+	Snoozes          []Snooze              `bson:"snoozes,omitempty" json:"snoozes,omitempty"`
+	Acknowledgements []Acknowledgement     `bson:"acknowledgements,omitempty" json:"acknowledgements,omitempty"`
+	NextFireAt       *time.Time            `bson:"next_fire_at,omitempty" json:"next_fire_at,omitempty"`
+	CreatedAt        time.Time             `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time             `bson:"updated_at" json:"updated_at"`
+	// ScheduleDone marks an event computed to never fire again, so the cron
+	// can tell it from one saved before next_fire_at was maintained.
+	ScheduleDone bool `bson:"schedule_done,omitempty" json:"-"`
 }
 
 type ReminderRule struct {
@@ -30,6 +45,132 @@ type ReminderRule struct {
 	ReminderBefore string  `bson:"reminder_before" json:"reminder_before"`
 	Enable         bool    `bson:"enable" json:"enable"`
 	Message        *string `bson:"message,omitempty" json:"message,omitempty"`
+	// At is the "15:04" local time a day-based reminder of an all-day event
+	// fires at, e.g. "1 day before at 08:00".
+	At *string `bson:"at,omitempty" json:"at,omitempty"`
+	// Escalation keeps re-sending the reminder until the occurrence is
+	// acknowledged.
+	Escalation *EscalationPolicy `bson:"escalation,omitempty" json:"escalation,omitempty"`
+	// Channels the reminder is delivered on; empty means push only.
+	Channels []string `bson:"channels,omitempty" json:"channels,omitempty"`
+}
+
+// EscalationPolicy re-sends an unacknowledged reminder every
+// RepeatEveryMinutes. Once MaxAttempts sends went unanswered, BackupUserID
+// (a parent or supervisor) is notified instead and the nagging stops.
+type EscalationPolicy struct {
+	RepeatEveryMinutes int64  `bson:"repeat_every_minutes" json:"repeat_every_minutes"`
+	MaxAttempts        int    `bson:"max_attempts" json:"max_attempts"`
+	BackupUserID       string `bson:"backup_user_id,omitempty" json:"backup_user_id,omitempty"`
+}
+
+// OccurrenceException skips or overrides the single instance of a recurring
+// event originally scheduled at OriginalStart.
+type OccurrenceException struct {
+	OriginalStart   time.Time  `bson:"original_start" json:"original_start"`
+	Cancelled       bool       `bson:"cancelled" json:"cancelled"`
+	StartDate       *time.Time `bson:"start_date,omitempty" json:"start_date,omitempty"`
+	DurationMinutes *int64     `bson:"duration_minutes,omitempty" json:"duration_minutes,omitempty"`
+	EventName       *string    `bson:"event_name,omitempty" json:"event_name,omitempty"`
+	Note            *string    `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// Snooze is a one-off follow-up reminder for the occurrence starting at
+// OccurrenceStart, delivered at RemindAt regardless of the reminder rules.
+type Snooze struct {
+	ID              primitive.ObjectID `bson:"_id" json:"id"`
+	OccurrenceStart time.Time          `bson:"occurrence_start" json:"occurrence_start"`
+	RemindAt        time.Time          `bson:"remind_at" json:"remind_at"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Acknowledgement records that the occurrence starting at OccurrenceStart
+// was acknowledged, which stops its reminders and escalations.
+type Acknowledgement struct {
+	OccurrenceStart time.Time `bson:"occurrence_start" json:"occurrence_start"`
+	AcknowledgedAt  time.Time `bson:"acknowledged_at" json:"acknowledged_at"`
+	AcknowledgedBy  string    `bson:"acknowledged_by,omitempty" json:"acknowledged_by,omitempty"`
+}
+
+// Escalation is the persisted nag progress of one reminder rule for one
+// occurrence, stored in its own collection so it survives restarts.
+type Escalation struct {
+	ID              primitive.ObjectID `bson:"_id" json:"id"`
+	EventID         primitive.ObjectID `bson:"event_id" json:"event_id"`
+	UserID          string             `bson:"user_id" json:"user_id"`
+	OccurrenceStart time.Time          `bson:"occurrence_start" json:"occurrence_start"`
+	RuleIndex       int                `bson:"rule_index" json:"rule_index"`
+	Attempts        int                `bson:"attempts" json:"attempts"`
+	NextAt          *time.Time         `bson:"next_at,omitempty" json:"next_at,omitempty"`
+	AcknowledgedAt  *time.Time         `bson:"acknowledged_at,omitempty" json:"acknowledged_at,omitempty"`
+	EscalatedAt     *time.Time         `bson:"escalated_at,omitempty" json:"escalated_at,omitempty"`
+	FinishedAt      *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Delivery is one attempt to deliver a notification to one device token,
+// kept so support staff can see why a reminder did or did not arrive.
+type Delivery struct {
+	ID               primitive.ObjectID `bson:"_id" json:"id"`
+	EventID          primitive.ObjectID `bson:"event_id" json:"event_id"`
+	OccurrenceStart  *time.Time         `bson:"occurrence_start,omitempty" json:"occurrence_start,omitempty"`
+	RuleIndex        *int               `bson:"rule_index,omitempty" json:"rule_index,omitempty"`
+	Trigger          string             `bson:"trigger" json:"trigger"`
+	UserID           string             `bson:"user_id" json:"user_id"`
+	TokenFingerprint string             `bson:"token_fingerprint,omitempty" json:"token_fingerprint,omitempty"`
+	Channel          string             `bson:"channel" json:"channel"`
+	Late             bool               `bson:"late,omitempty" json:"late,omitempty"`
+	Success          bool               `bson:"success" json:"success"`
+	MessageID        string             `bson:"message_id,omitempty" json:"message_id,omitempty"`
+	Error            string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Retry is a failed send to one target queued for another attempt. A send
+// that failed before the recipient's targets were known is queued without a
+// target and goes to all of them again. Retries that fail permanently or run
+// out of attempts are dead-lettered until an admin replays them.
+type Retry struct {
+	ID               primitive.ObjectID    `bson:"_id" json:"id"`
+	EventID          primitive.ObjectID    `bson:"event_id" json:"event_id"`
+	OccurrenceStart  *time.Time            `bson:"occurrence_start,omitempty" json:"occurrence_start,omitempty"`
+	RuleIndex        *int                  `bson:"rule_index,omitempty" json:"rule_index,omitempty"`
+	Trigger          string                `bson:"trigger" json:"trigger"`
+	UserID           string                `bson:"user_id" json:"user_id"`
+	Channel          string                `bson:"channel" json:"channel"`
+	Target           string                `bson:"target" json:"-"`
+	TokenFingerprint string                `bson:"token_fingerprint" json:"token_fingerprint"`
+	Notification     notifier.Notification `bson:"notification" json:"-"`
+	Status           string                `bson:"status" json:"status"`
+	Attempts         int                   `bson:"attempts" json:"attempts"`
+	NextAt           *time.Time            `bson:"next_at,omitempty" json:"next_at,omitempty"`
+	LastError        string                `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	DeadAt           *time.Time            `bson:"dead_at,omitempty" json:"dead_at,omitempty"`
+	DeliveredAt      *time.Time            `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	CreatedAt        time.Time             `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time             `bson:"updated_at" json:"updated_at"`
+}
+
+// Dispatch is the ledger entry claiming one reminder send. Its key is unique
+// so only one replica ever sends a given reminder.
+type Dispatch struct {
+	ID              primitive.ObjectID `bson:"_id" json:"id"`
+	EventID         primitive.ObjectID `bson:"event_id" json:"event_id"`
+	OccurrenceStart time.Time          `bson:"occurrence_start" json:"occurrence_start"`
+	RuleIndex       int                `bson:"rule_index" json:"rule_index"`
+	FireAt          time.Time          `bson:"fire_at" json:"fire_at"`
+	ClaimedBy       string             `bson:"claimed_by" json:"claimed_by"`
+	LeaseToken      int64              `bson:"lease_token,omitempty" json:"lease_token,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// SchedulerState is the progress of the reminder cron, kept so reminders
+// missed while no tick ran can be replayed.
+type SchedulerState struct {
+	Name      string    `bson:"_id" json:"name"`
+	LastTick  time.Time `bson:"last_tick" json:"last_tick"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
 type DayOption struct {
@@ -0,0 +1,283 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// seriesStarts returns the start instants the event's rule produces within
+// [from, to], limited to its start_date..end_date window, before any
+// per-occurrence exception is applied.
+func (s *eventService) seriesStarts(ev *Event, from, to time.Time) ([]time.Time, error) {
+
+	loc := s.eventLocation(ev)
+
+	rec, err := ev.recurrence(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	if rec == nil {
+		return nil, nil
+	}
+
+	end := ev.EndDate.In(loc)
+	if to.After(end) {
+		to = end
+	}
+
+	return rec.Between(ev.StartDate.In(loc), from, to), nil
+}
+
+// occurrences returns the occurrences starting within [from, to] once the
+// event's exceptions are applied: skipped instances are dropped and overridden
+// ones carry their new time, name and note. Both the cron path and the
+// listings go through here so they always agree.
+func (s *eventService) occurrences(ev *Event, from, to time.Time) ([]*OccurrenceResponse, error) {
+
+	loc := s.eventLocation(ev)
+
+	starts, err := s.seriesStarts(ev, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*OccurrenceResponse, 0, len(starts))
+
+	for _, start := range starts {
+		// Excepted instances are either skipped or re-added below at their
+		// overridden time, which may lie outside [from, to].
+		if ev.findException(start) != nil {
+			continue
+		}
+		result = append(result, s.newOccurrence(ev, start, nil))
+	}
+
+	for i := range ev.Exceptions {
+		exc := &ev.Exceptions[i]
+		if exc.Cancelled {
+			continue
+		}
+
+		occ := s.newOccurrence(ev, exc.OriginalStart.In(loc), exc)
+		if occ.Start.Before(from) || occ.Start.After(to) {
+			continue
+		}
+
+		// An override only applies while its original instance is still part
+		// of the series; editing the rule can orphan it.
+		original := exc.OriginalStart.In(loc)
+		valid, err := s.seriesStarts(ev, original, original)
+		if err != nil || len(valid) == 0 {
+			continue
+		}
+
+		result = append(result, occ)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+
+	return result, nil
+}
+
+func (s *eventService) newOccurrence(ev *Event, original time.Time, exc *OccurrenceException) *OccurrenceResponse {
+
+	loc := s.eventLocation(ev)
+
+	occ := &OccurrenceResponse{
+		OriginalStart: original,
+		Start:         original,
+		EventName:     ev.EventName,
+		Note:          ev.Note,
+	}
+
+	occ.AllDay = ev.AllDay
+	duration := ev.DurationMinutes
+
+	if exc != nil {
+		occ.Modified = true
+		if exc.StartDate != nil {
+			occ.Start = exc.StartDate.In(loc)
+		}
+		if exc.DurationMinutes != nil {
+			duration = *exc.DurationMinutes
+		}
+		if exc.EventName != nil {
+			occ.EventName = *exc.EventName
+		}
+		if exc.Note != nil {
+			occ.Note = *exc.Note
+		}
+	}
+
+	if ev.AllDay {
+		// Whole days end at local midnight, which is not always 24h away.
+		occ.End = occ.Start.AddDate(0, 0, int(ev.allDayLength(loc)))
+		return occ
+	}
+
+	occ.End = occ.Start.Add(time.Duration(duration) * time.Minute)
+
+	return occ
+}
+
+// allDayLength is how many days each occurrence of an all-day event covers.
+func (e *Event) allDayLength(loc *time.Location) int64 {
+
+	if e.isAllDaySpan() {
+		start := civilDate(e.StartDate.In(loc))
+		end := civilDate(e.EndDate.In(loc))
+		return int64(end.Sub(start).Hours()/24) + 1
+	}
+
+	if e.DurationDays > 0 {
+		return e.DurationDays
+	}
+
+	return 1
+}
+
+// expandOccurrences lists the occurrences of ev overlapping [from, to].
+func (s *eventService) expandOccurrences(ev *Event, from, to time.Time) ([]*OccurrenceResponse, error) {
+
+	// An occurrence that started before the window but is still running
+	// overlaps it, so look back by the longest duration.
+	lookback := ev.DurationMinutes
+	if ev.AllDay {
+		lookback = (ev.allDayLength(s.eventLocation(ev)) + 1) * 24 * 60
+	}
+	for _, exc := range ev.Exceptions {
+		if exc.DurationMinutes != nil && *exc.DurationMinutes > lookback {
+			lookback = *exc.DurationMinutes
+		}
+	}
+
+	occurrences, err := s.occurrences(ev, from.Add(-time.Duration(lookback)*time.Minute), to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rrule: %w", err)
+	}
+
+	result := make([]*OccurrenceResponse, 0, len(occurrences))
+	for _, occ := range occurrences {
+		if occ.End.Before(from) {
+			continue
+		}
+		result = append(result, occ)
+	}
+
+	return result, nil
+}
+
+// occurrenceOn resolves a "2006-01-02" date in the event's zone to the series
+// instance originally scheduled on that day.
+func (s *eventService) occurrenceOn(ev *Event, date string) (time.Time, error) {
+
+	loc := s.eventLocation(ev)
+
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %w", err)
+	}
+
+	starts, err := s.seriesStarts(ev, day, day.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid rrule: %w", err)
+	}
+
+	if len(starts) == 0 {
+		return time.Time{}, fmt.Errorf("event has no occurrence on %s", date)
+	}
+
+	return starts[0], nil
+}
+
+// reminderTimes returns when the cron path fires the first reminder of each
+// enabled rule for the occurrence starting at occ. Events the cron would
+// never notify (disabled, or with no expiration window) have none.
+func (s *eventService) reminderTimes(ev *Event, occ time.Time) []time.Time {
+
+	if !ev.IsSend || !ev.IsShow || ev.Schedule.Expiration <= 0 {
+		return nil
+	}
+
+	var times []time.Time
+	for _, rule := range ev.Reminders {
+		if !rule.Enable {
+			continue
+		}
+		times = append(times, s.reminderFireTime(ev, occ, rule))
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	return times
+}
+
+// defaultAllDayReminderAt is when day-based reminders of all-day events fire
+// if their rule sets no time of day.
+const defaultAllDayReminderAt = "09:00"
+
+// reminderFireTime returns when rule fires for the occurrence starting at
+// occ. Day-based rules of all-day events fire at the rule's local time of day
+// on the shifted date; everything else is a plain offset from the start.
+func (s *eventService) reminderFireTime(ev *Event, occ time.Time, rule ReminderRule) time.Time {
+
+	fire := s.subtractOffset(occ, rule)
+
+	if ev.AllDay && isDayBasedUnit(rule.ReminderBefore) {
+		hh, mm := rule.atClock()
+		fire = localTime(fire.Year(), fire.Month(), fire.Day(), hh, mm, 0, 0, fire.Location())
+	}
+
+	return fire.Truncate(time.Minute)
+}
+
+func isDayBasedUnit(unit string) bool {
+	return unit == "days" || unit == "weeks" || unit == "months"
+}
+
+func (r ReminderRule) atClock() (int, int) {
+	at := defaultAllDayReminderAt
+	if r.At != nil && *r.At != "" {
+		at = *r.At
+	}
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		t, _ = time.Parse("15:04", defaultAllDayReminderAt)
+	}
+	return t.Hour(), t.Minute()
+}
+
+func (e *Event) findException(original time.Time) *OccurrenceException {
+	for i := range e.Exceptions {
+		if e.Exceptions[i].OriginalStart.Equal(original) {
+			return &e.Exceptions[i]
+		}
+	}
+	return nil
+}
+
+// setException records exc, replacing any exception for the same instance.
+func (e *Event) setException(exc OccurrenceException) {
+	if existing := e.findException(exc.OriginalStart); existing != nil {
+		*existing = exc
+		return
+	}
+	e.Exceptions = append(e.Exceptions, exc)
+}
+
+// parseQueryTime accepts the same "2006-01-02 15:04:05" layout as the event
+// payloads, a bare date, or an RFC 3339 instant.
+func parseQueryTime(value string, loc *time.Location) (time.Time, error) {
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, loc); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
@@ -0,0 +1,54 @@
+package event
+
+import (
+	"net/url"
+	"strings"
+
+	"event-service/internal/notifier"
+)
+
+// reminderChannelID is the Android notification channel of reminders played
+// with the default sound. The apps create one channel per alarm sound, named
+// after the sound key, since a channel's sound cannot change once created.
+const reminderChannelID = "event_reminders"
+
+// pushOptions returns how a reminder about ev is presented on devices.
+// Reminders sent late after a scheduler gap are not marked time-sensitive.
+func pushOptions(ev *Event, notice reminderNotice) notifier.Push {
+
+	push := notifier.Push{
+		Sound:         ev.SoundKey,
+		ChannelID:     androidChannelID(ev.SoundKey),
+		ThreadID:      ev.ID.Hex(),
+		TimeSensitive: !notice.Late,
+	}
+
+	if isImageURL(ev.Icon) {
+		push.ImageURL = ev.Icon
+	}
+
+	return push
+}
+
+func androidChannelID(soundKey string) string {
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(soundKey) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+
+	if b.Len() == 0 {
+		return reminderChannelID
+	}
+
+	return reminderChannelID + "_" + b.String()
+}
+
+// isImageURL reports whether an event icon is an image URL rather than one
+// of the icon keys the apps bundle.
+func isImageURL(icon string) bool {
+	u, err := url.Parse(icon)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
@@ -0,0 +1,619 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the RFC 5545 FREQ rule part.
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+	FreqYearly  Frequency = "YEARLY"
+)
+
+// maxRecurrencePeriods caps how many FREQ periods are walked while expanding a
+// rule, so a rule that can never match (e.g. BYMONTHDAY=31;BYMONTH=2) terminates.
+const maxRecurrencePeriods = 100000
+
+// WeekdayNum is a BYDAY entry such as "TU", "2TU" (second Tuesday) or "-1FR"
+// (last Friday). Ordinal 0 means every such weekday in the period.
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// Recurrence is the subset of an RFC 5545 RRULE supported by the service:
+// FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH and WKST.
+type Recurrence struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	WeekStart  time.Weekday
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var rruleWeekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ParseRecurrence parses an RRULE value (with or without the "RRULE:" prefix).
+// Floating or date-only UNTIL values are interpreted in loc.
+func ParseRecurrence(value string, loc *time.Location) (*Recurrence, error) {
+
+	value = strings.TrimSpace(strings.ToUpper(value))
+	value = strings.TrimPrefix(value, "RRULE:")
+
+	if value == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	r := &Recurrence{Interval: 1, WeekStart: time.Monday}
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		key, val := kv[0], kv[1]
+
+		switch key {
+		case "FREQ":
+			switch Frequency(val) {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				r.Freq = Frequency(val)
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", val)
+			}
+			r.Count = n
+		case "UNTIL":
+			t, err := parseUntil(val, loc)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = t
+		case "BYDAY":
+			for _, item := range strings.Split(val, ",") {
+				wn, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, wn)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", item)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, item := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n < 1 || n > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH %q", item)
+				}
+				r.ByMonth = append(r.ByMonth, n)
+			}
+		case "WKST":
+			wd, ok := rruleWeekdays[val]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %q", val)
+			}
+			r.WeekStart = wd
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Validate checks the combinations of rule parts that RFC 5545 forbids.
+func (r *Recurrence) Validate() error {
+
+	if r.Freq == "" {
+		return errors.New("FREQ is required")
+	}
+
+	if r.Interval < 1 {
+		return errors.New("INTERVAL must be positive")
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return errors.New("COUNT and UNTIL must not both be set")
+	}
+
+	if r.Freq == FreqWeekly && len(r.ByMonthDay) > 0 {
+		return errors.New("BYMONTHDAY is not allowed with FREQ=WEEKLY")
+	}
+
+	for _, wn := range r.ByDay {
+		if wn.Ordinal == 0 {
+			continue
+		}
+		if r.Freq != FreqMonthly && r.Freq != FreqYearly {
+			return errors.New("BYDAY ordinals are only allowed with FREQ=MONTHLY or FREQ=YEARLY")
+		}
+		if r.Freq == FreqYearly && len(r.ByMonth) == 0 && (wn.Ordinal < -53 || wn.Ordinal > 53) {
+			return fmt.Errorf("BYDAY ordinal %d out of range", wn.Ordinal)
+		}
+		if (r.Freq == FreqMonthly || len(r.ByMonth) > 0) && (wn.Ordinal < -5 || wn.Ordinal > 5) {
+			return fmt.Errorf("BYDAY ordinal %d out of range", wn.Ordinal)
+		}
+	}
+
+	return nil
+}
+
+// String renders the rule in canonical RRULE value form, without the prefix.
+func (r *Recurrence) String() string {
+	return r.render(r.Until.UTC().Format("20060102T150405Z"))
+}
+
+// DateString renders the rule with UNTIL as a local DATE in loc, as RFC 5545
+// requires when DTSTART is a DATE.
+func (r *Recurrence) DateString(loc *time.Location) string {
+	return r.render(r.Until.In(loc).Format("20060102"))
+}
+
+func (r *Recurrence) render(until string) string {
+
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+until)
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wn := range r.ByDay {
+			days[i] = wn.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+rruleWeekdayCodes[r.WeekStart])
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func (w WeekdayNum) String() string {
+	if w.Ordinal == 0 {
+		return rruleWeekdayCodes[w.Weekday]
+	}
+	return strconv.Itoa(w.Ordinal) + rruleWeekdayCodes[w.Weekday]
+}
+
+// Between returns the instances of the rule anchored at dtstart that fall in
+// [from, to], in chronological order. Every instance keeps the wall-clock time
+// of dtstart in dtstart's location, so DST shifts do not move the local time;
+// a time skipped by a DST gap is moved forward by the gap's length.
+func (r *Recurrence) Between(dtstart, from, to time.Time) []time.Time {
+
+	var out []time.Time
+
+	loc := dtstart.Location()
+	hh, mm, ss := dtstart.Clock()
+	anchor := civilDate(dtstart)
+	count := 0
+
+	for period := 0; period < maxRecurrencePeriods; period += r.Interval {
+
+		periodStart, days := r.periodDays(anchor, period)
+
+		first := localTime(periodStart.Year(), periodStart.Month(), periodStart.Day(), hh, mm, ss, 0, loc)
+		if first.After(to) {
+			return out
+		}
+		if !r.Until.IsZero() && first.After(r.Until) {
+			return out
+		}
+
+		for _, d := range days {
+			t := localTime(d.Year(), d.Month(), d.Day(), hh, mm, ss, 0, loc)
+
+			if t.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return out
+			}
+
+			count++
+			if r.Count > 0 && count > r.Count {
+				return out
+			}
+
+			if t.After(to) {
+				return out
+			}
+			if !t.Before(from) {
+				out = append(out, t)
+			}
+		}
+	}
+
+	return out
+}
+
+// periodDays returns the first day of the n-th FREQ period after anchor and the
+// candidate days inside it, sorted and filtered by the BYxxx rule parts. Dates
+// are UTC midnights used purely as civil dates.
+func (r *Recurrence) periodDays(anchor time.Time, n int) (time.Time, []time.Time) {
+
+	switch r.Freq {
+	case FreqDaily:
+		day := anchor.AddDate(0, 0, n)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			return day, []time.Time{day}
+		}
+		return day, nil
+
+	case FreqWeekly:
+		offset := (int(anchor.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := anchor.AddDate(0, 0, -offset+7*n)
+		var days []time.Time
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() != anchor.Weekday() {
+				continue
+			}
+			if r.matchesWeekday(day) && r.matchesMonth(day) {
+				days = append(days, day)
+			}
+		}
+		return weekStart, days
+
+	case FreqMonthly:
+		monthStart := time.Date(anchor.Year(), anchor.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		if !r.matchesMonth(monthStart) {
+			return monthStart, nil
+		}
+		return monthStart, r.monthDays(anchor, monthStart)
+
+	case FreqYearly:
+		yearStart := time.Date(anchor.Year()+n, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return yearStart, r.yearDays(anchor, yearStart)
+	}
+
+	return anchor, nil
+}
+
+func (r *Recurrence) monthDays(anchor, monthStart time.Time) []time.Time {
+
+	monthEnd := monthStart.AddDate(0, 1, -1)
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		day := time.Date(monthStart.Year(), monthStart.Month(), anchor.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Month() != monthStart.Month() {
+			return nil
+		}
+		return []time.Time{day}
+	}
+
+	var byMonthDay, byDay []time.Time
+
+	for _, md := range r.ByMonthDay {
+		d := md
+		if d < 0 {
+			d = monthEnd.Day() + d + 1
+		}
+		if d < 1 || d > monthEnd.Day() {
+			continue
+		}
+		byMonthDay = append(byMonthDay, monthStart.AddDate(0, 0, d-1))
+	}
+
+	for _, wn := range r.ByDay {
+		byDay = append(byDay, weekdaysInRange(monthStart, monthEnd, wn)...)
+	}
+
+	switch {
+	case len(r.ByMonthDay) > 0 && len(r.ByDay) > 0:
+		return sortDays(intersectDays(byMonthDay, byDay))
+	case len(r.ByMonthDay) > 0:
+		return sortDays(byMonthDay)
+	default:
+		return sortDays(byDay)
+	}
+}
+
+func (r *Recurrence) yearDays(anchor, yearStart time.Time) []time.Time {
+
+	if len(r.ByMonth) > 0 || (len(r.ByDay) == 0 && len(r.ByMonthDay) > 0) {
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		}
+		var days []time.Time
+		for _, m := range months {
+			monthStart := time.Date(yearStart.Year(), time.Month(m), 1, 0, 0, 0, 0, time.UTC)
+			if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+				day := time.Date(yearStart.Year(), time.Month(m), anchor.Day(), 0, 0, 0, 0, time.UTC)
+				if day.Month() == time.Month(m) {
+					days = append(days, day)
+				}
+				continue
+			}
+			days = append(days, r.monthDays(anchor, monthStart)...)
+		}
+		return sortDays(days)
+	}
+
+	if len(r.ByDay) > 0 {
+		yearEnd := yearStart.AddDate(1, 0, -1)
+		var days []time.Time
+		for _, wn := range r.ByDay {
+			days = append(days, weekdaysInRange(yearStart, yearEnd, wn)...)
+		}
+		if len(r.ByMonthDay) > 0 {
+			filtered := days[:0]
+			for _, d := range days {
+				if r.matchesMonthDay(d) {
+					filtered = append(filtered, d)
+				}
+			}
+			days = filtered
+		}
+		return sortDays(days)
+	}
+
+	day := time.Date(yearStart.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Month() != anchor.Month() {
+		return nil
+	}
+	return []time.Time{day}
+}
+
+func (r *Recurrence) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if time.Month(m) == day.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recurrence) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || (md < 0 && last+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recurrence) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wn := range r.ByDay {
+		if wn.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdaysInRange returns the days in [first, last] falling on wn.Weekday,
+// narrowed to the n-th (or n-th from last) one when wn has an ordinal.
+func weekdaysInRange(first, last time.Time, wn WeekdayNum) []time.Time {
+
+	var days []time.Time
+	offset := (int(wn.Weekday) - int(first.Weekday()) + 7) % 7
+	for d := first.AddDate(0, 0, offset); !d.After(last); d = d.AddDate(0, 0, 7) {
+		days = append(days, d)
+	}
+
+	switch {
+	case wn.Ordinal > 0:
+		if wn.Ordinal > len(days) {
+			return nil
+		}
+		return days[wn.Ordinal-1 : wn.Ordinal]
+	case wn.Ordinal < 0:
+		idx := len(days) + wn.Ordinal
+		if idx < 0 {
+			return nil
+		}
+		return days[idx : idx+1]
+	}
+
+	return days
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+
+	code := value[len(value)-2:]
+	wd, ok := rruleWeekdays[code]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+
+	wn := WeekdayNum{Weekday: wd}
+
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(prefix, "+"))
+		if err != nil || n == 0 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+		}
+		wn.Ordinal = n
+	}
+
+	return wn, nil
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+
+	// A date-only UNTIL includes the whole of that day.
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// recurrence returns the event's schedule as a rule: its RRULE when set,
+// otherwise the rule equivalent to its legacy day_selections. A nil rule with a
+// nil error means the event has no occurrences at all.
+func (e *Event) recurrence(loc *time.Location) (*Recurrence, error) {
+	if e.RRule != "" {
+		return ParseRecurrence(e.RRule, loc)
+	}
+	if e.isAllDaySpan() {
+		return &Recurrence{Freq: FreqDaily, Interval: 1, Count: 1, WeekStart: time.Monday}, nil
+	}
+	return legacyRecurrence(e.Schedule), nil
+}
+
+// isAllDaySpan reports whether the event is a single all-day occurrence
+// covering every day from start_date to end_date, rather than a series.
+func (e *Event) isAllDaySpan() bool {
+	return e.AllDay && e.RRule == "" && len(e.Schedule.Day) == 0
+}
+
+// legacyRecurrence maps the pre-RRULE day_selections schedule onto the
+// equivalent rule: a daily occurrence, optionally limited to some weekdays.
+// It returns nil when the selection names no valid weekday, which never
+// matched any occurrence.
+func legacyRecurrence(schedule ScheduleSettings) *Recurrence {
+
+	r := &Recurrence{Freq: FreqDaily, Interval: 1, WeekStart: time.Monday}
+
+	for _, d := range schedule.Day {
+		if wd, ok := weekdayFromName(d.Key); ok {
+			r.ByDay = append(r.ByDay, WeekdayNum{Weekday: wd})
+		}
+	}
+
+	if len(schedule.Day) > 0 && len(r.ByDay) == 0 {
+		return nil
+	}
+
+	return r
+}
+
+func weekdayFromName(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.ToLower(wd.String()) == name {
+			return wd, true
+		}
+	}
+	return 0, false
+}
+
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func sortDays(days []time.Time) []time.Time {
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	out := days[:0]
+	for i, d := range days {
+		if i > 0 && d.Equal(days[i-1]) {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func intersectDays(a, b []time.Time) []time.Time {
+	var out []time.Time
+	for _, x := range a {
+		for _, y := range b {
+			if x.Equal(y) {
+				out = append(out, x)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,252 @@
+package event
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceBetweenDST(t *testing.T) {
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tz database unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		dtstart time.Time
+		day     time.Time
+		want    string
+	}{
+		{
+			// 02:30 does not exist on the spring-forward day; RFC 5545 moves
+			// it forward by the gap.
+			name:    "spring forward gap",
+			dtstart: time.Date(2025, time.March, 1, 2, 30, 0, 0, ny),
+			day:     time.Date(2025, time.March, 9, 0, 0, 0, 0, ny),
+			want:    "2025-03-09T03:30:00-04:00",
+		},
+		{
+			name:    "after spring forward",
+			dtstart: time.Date(2025, time.March, 1, 2, 30, 0, 0, ny),
+			day:     time.Date(2025, time.March, 10, 0, 0, 0, 0, ny),
+			want:    "2025-03-10T02:30:00-04:00",
+		},
+		{
+			// 01:30 happens twice on the fall-back day; the first one is used.
+			name:    "fall back overlap",
+			dtstart: time.Date(2025, time.October, 1, 1, 30, 0, 0, ny),
+			day:     time.Date(2025, time.November, 2, 0, 0, 0, 0, ny),
+			want:    "2025-11-02T01:30:00-04:00",
+		},
+		{
+			name:    "after fall back",
+			dtstart: time.Date(2025, time.October, 1, 1, 30, 0, 0, ny),
+			day:     time.Date(2025, time.November, 3, 0, 0, 0, 0, ny),
+			want:    "2025-11-03T01:30:00-05:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			rec, err := ParseRecurrence("FREQ=DAILY", ny)
+			if err != nil {
+				t.Fatalf("ParseRecurrence: %v", err)
+			}
+
+			got := rec.Between(tt.dtstart, tt.day, tt.day.Add(24*time.Hour-time.Second))
+			if len(got) != 1 {
+				t.Fatalf("got %d occurrences, want 1: %v", len(got), got)
+			}
+
+			if s := got[0].Format(time.RFC3339); s != tt.want {
+				t.Errorf("got %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tz database unavailable: %v", err)
+	}
+
+	utc := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		rrule   string
+		dtstart time.Time
+		from    time.Time
+		to      time.Time
+		want    []string
+	}{
+		{
+			name:    "second tuesday",
+			rrule:   "FREQ=MONTHLY;BYDAY=2TU;COUNT=3",
+			dtstart: utc(2025, time.January, 1, 9),
+			want:    []string{"2025-01-14T09:00:00Z", "2025-02-11T09:00:00Z", "2025-03-11T09:00:00Z"},
+		},
+		{
+			name:    "last friday",
+			rrule:   "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			dtstart: utc(2025, time.January, 1, 9),
+			want:    []string{"2025-01-31T09:00:00Z", "2025-02-28T09:00:00Z", "2025-03-28T09:00:00Z"},
+		},
+		{
+			name:    "fourth thursday of november",
+			rrule:   "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=2",
+			dtstart: utc(2025, time.January, 1, 9),
+			want:    []string{"2025-11-27T09:00:00Z", "2026-11-26T09:00:00Z"},
+		},
+		{
+			name:    "last day of the month",
+			rrule:   "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=4",
+			dtstart: utc(2024, time.January, 1, 9),
+			want:    []string{"2024-01-31T09:00:00Z", "2024-02-29T09:00:00Z", "2024-03-31T09:00:00Z", "2024-04-30T09:00:00Z"},
+		},
+		{
+			name:    "31st skips short months",
+			rrule:   "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3",
+			dtstart: utc(2025, time.January, 1, 9),
+			want:    []string{"2025-01-31T09:00:00Z", "2025-03-31T09:00:00Z", "2025-05-31T09:00:00Z"},
+		},
+		{
+			name:    "every third day",
+			rrule:   "FREQ=DAILY;INTERVAL=3;COUNT=3",
+			dtstart: utc(2025, time.January, 30, 9),
+			want:    []string{"2025-01-30T09:00:00Z", "2025-02-02T09:00:00Z", "2025-02-05T09:00:00Z"},
+		},
+		{
+			name:    "every other week on two days",
+			rrule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=4",
+			dtstart: utc(2025, time.January, 6, 9),
+			want:    []string{"2025-01-06T09:00:00Z", "2025-01-08T09:00:00Z", "2025-01-20T09:00:00Z", "2025-01-22T09:00:00Z"},
+		},
+		{
+			// DTSTART is a Wednesday and does not match BYDAY.
+			name:    "dtstart outside the rule",
+			rrule:   "FREQ=WEEKLY;BYDAY=FR;COUNT=2",
+			dtstart: utc(2025, time.January, 1, 9),
+			want:    []string{"2025-01-03T09:00:00Z", "2025-01-10T09:00:00Z"},
+		},
+		{
+			// COUNT includes the instances before the window.
+			name:    "count before the window",
+			rrule:   "FREQ=DAILY;COUNT=5",
+			dtstart: utc(2025, time.January, 1, 9),
+			from:    utc(2025, time.January, 4, 0),
+			want:    []string{"2025-01-04T09:00:00Z", "2025-01-05T09:00:00Z"},
+		},
+		{
+			name:    "until is inclusive",
+			rrule:   "FREQ=DAILY;UNTIL=20250103T090000Z",
+			dtstart: utc(2025, time.January, 1, 9),
+			want:    []string{"2025-01-01T09:00:00Z", "2025-01-02T09:00:00Z", "2025-01-03T09:00:00Z"},
+		},
+		{
+			name:    "window",
+			rrule:   "FREQ=DAILY",
+			dtstart: utc(2025, time.January, 1, 9),
+			from:    utc(2025, time.March, 1, 0),
+			to:      utc(2025, time.March, 2, 23),
+			want:    []string{"2025-03-01T09:00:00Z", "2025-03-02T09:00:00Z"},
+		},
+		{
+			name:    "weekly across spring forward",
+			rrule:   "FREQ=WEEKLY;COUNT=3",
+			dtstart: time.Date(2025, time.March, 2, 9, 0, 0, 0, ny),
+			want:    []string{"2025-03-02T09:00:00-05:00", "2025-03-09T09:00:00-04:00", "2025-03-16T09:00:00-04:00"},
+		},
+		{
+			name:    "monthly across fall back",
+			rrule:   "FREQ=MONTHLY;COUNT=2",
+			dtstart: time.Date(2025, time.October, 15, 9, 0, 0, 0, ny),
+			want:    []string{"2025-10-15T09:00:00-04:00", "2025-11-15T09:00:00-05:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			rec, err := ParseRecurrence(tt.rrule, tt.dtstart.Location())
+			if err != nil {
+				t.Fatalf("ParseRecurrence: %v", err)
+			}
+
+			from, to := tt.from, tt.to
+			if from.IsZero() {
+				from = tt.dtstart
+			}
+			if to.IsZero() {
+				to = tt.dtstart.AddDate(3, 0, 0)
+			}
+
+			var got []string
+			for _, occ := range rec.Between(tt.dtstart, from, to) {
+				got = append(got, occ.Format(time.RFC3339))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOccurrencesExceptions(t *testing.T) {
+
+	day := func(d int) time.Time {
+		return time.Date(2025, time.January, d, 9, 0, 0, 0, time.UTC)
+	}
+
+	moved := day(4).Add(3 * time.Hour)
+	renamed := "Moved standup"
+
+	ev := &Event{
+		EventName:       "Standup",
+		StartDate:       day(1),
+		EndDate:         day(31),
+		DurationMinutes: 15,
+		RRule:           "FREQ=DAILY;COUNT=5",
+		TimeZone:        "UTC",
+		Exceptions: []OccurrenceException{
+			{OriginalStart: day(3), Cancelled: true},
+			{OriginalStart: day(4), StartDate: &moved, EventName: &renamed},
+			// Not an instance of the series, so it is ignored.
+			{OriginalStart: day(20), StartDate: &moved},
+		},
+	}
+
+	s := &eventService{location: time.UTC}
+
+	occs, err := s.occurrences(ev, day(1), day(31))
+	if err != nil {
+		t.Fatalf("occurrences: %v", err)
+	}
+
+	want := []struct {
+		start string
+		name  string
+	}{
+		{"2025-01-01T09:00:00Z", "Standup"},
+		{"2025-01-02T09:00:00Z", "Standup"},
+		{"2025-01-04T12:00:00Z", "Moved standup"},
+		{"2025-01-05T09:00:00Z", "Standup"},
+	}
+
+	if len(occs) != len(want) {
+		t.Fatalf("got %d occurrences, want %d", len(occs), len(want))
+	}
+	for i, w := range want {
+		if got := occs[i].Start.Format(time.RFC3339); got != w.start || occs[i].EventName != w.name {
+			t.Errorf("occurrence %d = %s %q, want %s %q", i, got, occs[i].EventName, w.start, w.name)
+		}
+	}
+}
@@ -14,21 +14,29 @@ import (
 type EventRepository interface {
 	Create(ctx context.Context, event *Event) error
 	FindEventActive(ctx context.Context) ([]*Event, error)
+	FindDueEvents(ctx context.Context, now time.Time) ([]*Event, error)
+	SetNextFireAt(ctx context.Context, id primitive.ObjectID, next *time.Time, updatedAt time.Time) error
 	FindAllEvents(ctx context.Context, userID string) ([]*Event, error)
 	FindEventByID(ctx context.Context, eventID primitive.ObjectID) (*Event, error)
 	UpdateEvent(ctx context.Context, event *Event, id primitive.ObjectID) error
 	DeleteEvent(ctx context.Context, id primitive.ObjectID) error
+	SaveSnooze(ctx context.Context, id primitive.ObjectID, snooze *Snooze) error
+	FindEventsWithDueSnoozes(ctx context.Context, now time.Time) ([]*Event, error)
+	RemoveSnooze(ctx context.Context, id primitive.ObjectID, snoozeID primitive.ObjectID) (bool, error)
+	AddAcknowledgement(ctx context.Context, id primitive.ObjectID, ack *Acknowledgement) error
 }
 
 type eventRepository struct {
 	collection *mongo.Collection
 }
 
-func NewEventRepository(collection *mongo.Collection) EventRepository {
-	_ = EnsureEventIndexes(context.Background(), collection)
+func NewEventRepository(collection *mongo.Collection) (EventRepository, error) {
+	if err := EnsureEventIndexes(context.Background(), collection); err != nil {
+		return nil, err
+	}
 	return &eventRepository{
 		collection: collection,
-	}
+	}, nil
 }
 
 func (e *eventRepository) Create(ctx context.Context, event *Event) error {
@@ -83,6 +91,62 @@ func (e *eventRepository) FindEventActive(ctx context.Context) ([]*Event, error)
 	return events, nil
 }
 
+// FindDueEvents returns the events whose next reminder is due by now.
+// Events saved before next_fire_at was maintained carry neither it nor
+// schedule_done; those that have not ended are returned too, so the cron
+// computes it once.
+func (e *eventRepository) FindDueEvents(ctx context.Context, now time.Time) ([]*Event, error) {
+
+	var events []*Event
+
+	filter := bson.M{
+		"is_send": true,
+		"$or": bson.A{
+			bson.M{"next_fire_at": bson.M{"$lte": now}},
+			bson.M{
+				"next_fire_at":  bson.M{"$exists": false},
+				"schedule_done": bson.M{"$exists": false},
+				"end_date":      bson.M{"$gte": now},
+			},
+		},
+	}
+
+	cursor, err := e.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// SetNextFireAt stores next_fire_at, or marks the schedule done when next is
+// nil, unless the event was updated after updatedAt.
+func (e *eventRepository) SetNextFireAt(ctx context.Context, id primitive.ObjectID, next *time.Time, updatedAt time.Time) error {
+
+	update := bson.M{
+		"$unset": bson.M{"next_fire_at": ""},
+		"$set":   bson.M{"schedule_done": true},
+	}
+	if next != nil {
+		update = bson.M{
+			"$set":   bson.M{"next_fire_at": *next},
+			"$unset": bson.M{"schedule_done": ""},
+		}
+	}
+
+	_, err := e.collection.UpdateOne(ctx, bson.M{"_id": id, "updated_at": updatedAt}, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (e *eventRepository) FindAllEvents(ctx context.Context, userID string) ([]*Event, error) {
 
 	var events []*Event
@@ -118,7 +182,12 @@ func (e *eventRepository) FindEventByID(ctx context.Context, eventID primitive.O
 
 func (e *eventRepository) UpdateEvent(ctx context.Context, event *Event, id primitive.ObjectID) error {
 
-	_, err := e.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": event})
+	update := bson.M{"$set": editedFields(event)}
+	if unset := clearedFields(event); len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	_, err := e.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
 	if err != nil {
 		return err
 	}
@@ -126,6 +195,86 @@ func (e *eventRepository) UpdateEvent(ctx context.Context, event *Event, id prim
 
 }
 
+// editedFields returns the fields an update of the event writes. Snoozes
+// and acknowledgements are left out: they are only changed by their own
+// atomic updates, which a write of a stale copy would undo.
+func editedFields(event *Event) bson.M {
+
+	set := bson.M{
+		"event_name":         event.EventName,
+		"start_date":         event.StartDate,
+		"end_date":           event.EndDate,
+		"is_show":            event.IsShow,
+		"is_send":            event.IsSend,
+		"sound_key":          event.SoundKey,
+		"sound_repeat_times": event.SoundRepeatTimes,
+		"icon":               event.Icon,
+		"note":               event.Note,
+		"url":                event.Url,
+		"reminder_settings":  event.Reminders,
+		"scheduled_settings": event.Schedule,
+		"duration_minutes":   event.DurationMinutes,
+		"all_day":            event.AllDay,
+		"updated_at":         event.UpdatedAt,
+	}
+
+	if event.RRule != "" {
+		set["rrule"] = event.RRule
+	}
+	if event.TimeZone != "" {
+		set["time_zone"] = event.TimeZone
+	}
+	if event.Locale != "" {
+		set["locale"] = event.Locale
+	}
+	if len(event.Exceptions) > 0 {
+		set["exceptions"] = event.Exceptions
+	}
+	if event.DurationDays != 0 {
+		set["duration_days"] = event.DurationDays
+	}
+	if event.NextFireAt != nil {
+		set["next_fire_at"] = *event.NextFireAt
+	}
+	if event.ScheduleDone {
+		set["schedule_done"] = true
+	}
+
+	return set
+}
+
+// clearedFields returns the optional fields of event that are empty, which
+// editedFields does not write, so a cleared rrule, zone, locale or duration
+// does not keep its stored value.
+func clearedFields(event *Event) bson.M {
+
+	unset := bson.M{}
+
+	if event.RRule == "" {
+		unset["rrule"] = ""
+	}
+	if event.TimeZone == "" {
+		unset["time_zone"] = ""
+	}
+	if event.Locale == "" {
+		unset["locale"] = ""
+	}
+	if len(event.Exceptions) == 0 {
+		unset["exceptions"] = ""
+	}
+	if event.DurationDays == 0 {
+		unset["duration_days"] = ""
+	}
+	if event.NextFireAt == nil {
+		unset["next_fire_at"] = ""
+	}
+	if !event.ScheduleDone {
+		unset["schedule_done"] = ""
+	}
+
+	return unset
+}
+
 func (e *eventRepository) DeleteEvent(ctx context.Context, id primitive.ObjectID) error {
 
 	_, err := e.collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -137,6 +286,78 @@ func (e *eventRepository) DeleteEvent(ctx context.Context, id primitive.ObjectID
 
 }
 
+// SaveSnooze stores the snooze, replacing any pending one for the same
+// occurrence.
+func (e *eventRepository) SaveSnooze(ctx context.Context, id primitive.ObjectID, snooze *Snooze) error {
+
+	_, err := e.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$pull": bson.M{"snoozes": bson.M{"occurrence_start": snooze.OccurrenceStart}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = e.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$push": bson.M{"snoozes": snooze},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (e *eventRepository) FindEventsWithDueSnoozes(ctx context.Context, now time.Time) ([]*Event, error) {
+
+	var events []*Event
+
+	cursor, err := e.collection.Find(ctx, bson.M{"snoozes.remind_at": bson.M{"$lte": now}})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+
+}
+
+// RemoveSnooze pulls the snooze off the event and reports whether this call
+// removed it, so only one caller ever delivers it.
+func (e *eventRepository) RemoveSnooze(ctx context.Context, id primitive.ObjectID, snoozeID primitive.ObjectID) (bool, error) {
+
+	res, err := e.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "snoozes._id": snoozeID},
+		bson.M{"$pull": bson.M{"snoozes": bson.M{"_id": snoozeID}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount == 1, nil
+
+}
+
+// AddAcknowledgement records the acknowledgement unless the occurrence was
+// already acknowledged.
+func (e *eventRepository) AddAcknowledgement(ctx context.Context, id primitive.ObjectID, ack *Acknowledgement) error {
+
+	_, err := e.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "acknowledgements.occurrence_start": bson.M{"$ne": ack.OccurrenceStart}},
+		bson.M{"$push": bson.M{"acknowledgements": ack}},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
 func EnsureEventIndexes(ctx context.Context, coll *mongo.Collection) error {
 
 	models := []mongo.IndexModel{
@@ -157,6 +378,22 @@ func EnsureEventIndexes(ctx context.Context, coll *mongo.Collection) error {
 			Options: options.Index().
 				SetName("by_user_created"),
 		},
+		{
+			Keys: bson.D{
+				{Key: "next_fire_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("next_fire_at").
+				SetSparse(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "snoozes.remind_at", Value: 1},
+			},
+			Options: options.Index().
+				SetName("snooze_remind_at").
+				SetSparse(true),
+		},
 	}
 	_, err := coll.Indexes().CreateMany(ctx, models)
 	return err
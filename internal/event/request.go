@@ -14,6 +14,33 @@ type CreateEventRequest struct {
 	Url              string           `json:"url"`
 	Reminders        []ReminderRule   `json:"reminder_settings"`
 	Schedule         ScheduleSettings `json:"scheduled_settings"`
+	RRule            string           `json:"rrule"`
+	DurationMinutes  int64            `json:"duration_minutes"`
+	TimeZone         string           `json:"time_zone"`
+	Locale           string           `json:"locale"`
+	AllDay           bool             `json:"all_day"`
+	DurationDays     int64            `json:"duration_days"`
+}
+
+type OccurrenceOverrideRequest struct {
+	StartDate       *string `json:"start_date,omitempty"`
+	DurationMinutes *int64  `json:"duration_minutes,omitempty"`
+	EventName       *string `json:"event_name,omitempty"`
+	Note            *string `json:"note,omitempty"`
+}
+
+// SnoozeRequest snoozes the current occurrence, or the one starting at
+// Occurrence, for Minutes minutes or until Until.
+type SnoozeRequest struct {
+	Minutes    int64   `json:"minutes,omitempty"`
+	Until      *string `json:"until,omitempty"`
+	Occurrence *string `json:"occurrence,omitempty"`
+}
+
+// AcknowledgeRequest acknowledges the current occurrence, or the one
+// starting at Occurrence.
+type AcknowledgeRequest struct {
+	Occurrence *string `json:"occurrence,omitempty"`
 }
 
 type TriggerEventRequest struct {
@@ -33,4 +60,10 @@ type UpdateEventRequest struct {
 	Url              *string           `json:"url,omitempty"`
 	Reminders        *[]ReminderRule   `json:"reminder_settings,omitempty"`
 	Schedule         *ScheduleSettings `json:"scheduled_settings,omitempty"`
+	RRule            *string           `json:"rrule,omitempty"`
+	DurationMinutes  *int64            `json:"duration_minutes,omitempty"`
+	TimeZone         *string           `json:"time_zone,omitempty"`
+	Locale           *string           `json:"locale,omitempty"`
+	AllDay           *bool             `json:"all_day,omitempty"`
+	DurationDays     *int64            `json:"duration_days,omitempty"`
 }
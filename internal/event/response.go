@@ -1 +1,51 @@
-package event
\ No newline at end of file
+package event
+
+import "time"
+
+type OccurrenceResponse struct {
+	OriginalStart time.Time `json:"original_start"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	EventName     string    `json:"event_name"`
+	Note          string    `json:"note"`
+	Modified      bool      `json:"modified"`
+	AllDay        bool      `json:"all_day"`
+}
+
+type CalendarItemResponse struct {
+	EventID   string      `json:"event_id"`
+	EventName string      `json:"event_name"`
+	Icon      string      `json:"icon"`
+	TimeZone  string      `json:"time_zone"`
+	AllDay    bool        `json:"all_day"`
+	Start     time.Time   `json:"start"`
+	End       time.Time   `json:"end"`
+	Reminders []time.Time `json:"reminders"`
+}
+
+type ImportResultResponse struct {
+	Imported int                   `json:"imported"`
+	Failed   int                   `json:"failed"`
+	Items    []*ImportItemResponse `json:"items"`
+}
+
+type ImportItemResponse struct {
+	Index    int      `json:"index"`
+	UID      string   `json:"uid"`
+	Summary  string   `json:"summary"`
+	Success  bool     `json:"success"`
+	EventID  string   `json:"event_id,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type SnoozeResponse struct {
+	ID              string    `json:"id"`
+	EventID         string    `json:"event_id"`
+	OccurrenceStart time.Time `json:"occurrence_start"`
+	RemindAt        time.Time `json:"remind_at"`
+}
+
+type ReplayRetriesResponse struct {
+	Replayed int64 `json:"replayed"`
+}
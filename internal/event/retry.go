@@ -0,0 +1,263 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"event-service/internal/notifier"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Retry statuses. Pending retries carry next_at; delivered and dead ones do
+// not, so only pending ones are picked up by the cron.
+const (
+	retryPending   = "pending"
+	retryDelivered = "delivered"
+	retryDead      = "dead"
+)
+
+// A failed send is retried up to maxRetryAttempts times. The delay doubles
+// from retryBaseDelay up to retryMaxDelay, with up to half of it jittered so
+// retries of one outage do not all land on the same tick.
+const (
+	maxRetryAttempts = 8
+	retryBaseDelay   = 30 * time.Second
+	retryMaxDelay    = 30 * time.Minute
+	retriesPerTick   = 500
+)
+
+const (
+	defaultRetryLimit = 100
+	maxRetryLimit     = 500
+)
+
+// retryBackoff returns the delay before the given retry attempt (1-based).
+func retryBackoff(attempt int) time.Duration {
+
+	d := retryMaxDelay
+	if attempt < 16 {
+		d = min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	}
+
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// enqueueRetry queues a failed send for another attempt when the channel
+// supports retries and the failure is transient. A result without a target
+// is a send that failed before the recipient's targets were known; its retry
+// sends to all of them again.
+func (s *eventService) enqueueRetry(ctx context.Context, ev *Event, notice reminderNotice, n *notifier.Notification, channel string, nt notifier.Notifier, result notifier.Result) {
+
+	retrier, ok := nt.(notifier.Retrier)
+	if !ok || errors.Is(result.Err, notifier.ErrNoTargets) || !retrier.Retryable(result.Err) {
+		return
+	}
+
+	now := time.Now()
+	next := now.Add(retryBackoff(1)).Truncate(time.Millisecond)
+
+	retry := &Retry{
+		ID:           primitive.NewObjectID(),
+		EventID:      ev.ID,
+		RuleIndex:    notice.RuleIndex,
+		Trigger:      notice.Trigger,
+		UserID:       n.UserID,
+		Channel:      channel,
+		Target:       result.Target,
+		Notification: *n,
+		Status:       retryPending,
+		NextAt:       &next,
+		LastError:    result.Err.Error(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if result.Target != "" {
+		retry.TokenFingerprint = tokenFingerprint(result.Target)
+	}
+
+	if !notice.Occurrence.IsZero() {
+		occ := notice.Occurrence
+		retry.OccurrenceStart = &occ
+	}
+
+	if err := s.retryRepository.Create(ctx, retry); err != nil {
+		log.Printf("❌ Error queueing retry for event %s: %v", ev.EventName, err)
+		return
+	}
+
+	log.Printf("🔁 Queued retry for event %s on %s (token %s)", ev.EventName, channel, retry.TokenFingerprint)
+}
+
+func (s *eventService) processRetries(ctx context.Context, now time.Time) {
+
+	retries, err := s.retryRepository.FindDue(ctx, now, retriesPerTick)
+	if err != nil {
+		log.Printf("❌ Error FindDue retries: %v", err)
+		return
+	}
+
+	for _, retry := range retries {
+		s.attemptRetry(ctx, retry, now)
+	}
+}
+
+func (s *eventService) attemptRetry(ctx context.Context, retry *Retry, now time.Time) {
+
+	prevNextAt := *retry.NextAt
+
+	nt, _ := s.notifiers.Get(retry.Channel)
+	retrier, _ := nt.(notifier.Retrier)
+
+	if retrier == nil {
+		retry.deadLetter(now, fmt.Errorf("channel %q does not support retries", retry.Channel))
+		if _, err := s.retryRepository.Update(ctx, retry, prevNextAt); err != nil {
+			log.Printf("❌ Error dead-lettering retry %s: %v", retry.ID.Hex(), err)
+		}
+		return
+	}
+
+	// Claim the attempt by moving next_at to when the following one is due,
+	// so a crash mid-send still leaves the retry scheduled.
+	retry.Attempts++
+	retry.UpdatedAt = now
+	next := now.Add(retryBackoff(retry.Attempts + 1)).Truncate(time.Millisecond)
+	retry.NextAt = &next
+
+	var won bool
+	err := s.fenced(ctx, func(ctx context.Context) (err error) {
+		won, err = s.retryRepository.Update(ctx, retry, prevNextAt)
+		return err
+	})
+	if err != nil {
+		log.Printf("❌ Error claiming retry %s: %v", retry.ID.Hex(), err)
+		return
+	}
+	if !won {
+		return
+	}
+
+	notice := reminderNotice{
+		Trigger:   triggerRetry,
+		RuleIndex: retry.RuleIndex,
+	}
+	if retry.OccurrenceStart != nil {
+		notice.Occurrence = *retry.OccurrenceStart
+	}
+	ev := &Event{ID: retry.EventID, EventName: retry.Notification.Subject.EventName}
+
+	var result notifier.Result
+	if retry.Target == "" {
+		result = s.renotify(ctx, ev, notice, retry, nt)
+	} else {
+		result = retrier.NotifyTarget(ctx, &retry.Notification, retry.Target)
+		s.recordDelivery(ctx, ev, notice, retry.UserID, retry.Channel, result.Target, result.MessageID, result.Err)
+	}
+
+	switch {
+	case result.Err == nil:
+		log.Printf("✅ Retry %s delivered after %d attempts", retry.ID.Hex(), retry.Attempts)
+		retry.Status = retryDelivered
+		retry.NextAt = nil
+		retry.DeliveredAt = &now
+		retry.LastError = ""
+
+	case errors.Is(result.Err, notifier.ErrNoTargets), !retrier.Retryable(result.Err):
+		log.Printf("⛔ Retry %s failed permanently: %v", retry.ID.Hex(), result.Err)
+		retry.deadLetter(now, result.Err)
+
+	case retry.Attempts >= maxRetryAttempts:
+		log.Printf("⛔ Retry %s gave up after %d attempts: %v", retry.ID.Hex(), retry.Attempts, result.Err)
+		retry.deadLetter(now, result.Err)
+
+	default:
+		retry.LastError = result.Err.Error()
+	}
+
+	if _, err := s.retryRepository.Update(ctx, retry, next); err != nil {
+		log.Printf("❌ Error saving retry %s: %v", retry.ID.Hex(), err)
+	}
+}
+
+// renotify repeats a send that failed before the recipient's targets were
+// known. Once the channel is reached the retry is done: targets that then
+// fail get retries of their own, queued under the original trigger.
+func (s *eventService) renotify(ctx context.Context, ev *Event, notice reminderNotice, retry *Retry, nt notifier.Notifier) notifier.Result {
+
+	results, err := nt.Notify(ctx, &retry.Notification)
+	if err != nil {
+		s.recordDelivery(ctx, ev, notice, retry.UserID, retry.Channel, "", "", err)
+		return notifier.Result{Err: err}
+	}
+
+	original := notice
+	original.Trigger = retry.Trigger
+
+	for _, r := range results {
+		s.recordDelivery(ctx, ev, notice, retry.UserID, retry.Channel, r.Target, r.MessageID, r.Err)
+		if r.Err != nil {
+			s.enqueueRetry(ctx, ev, original, &retry.Notification, retry.Channel, nt, r)
+		}
+	}
+
+	return notifier.Result{}
+}
+
+func (r *Retry) deadLetter(now time.Time, err error) {
+	r.Status = retryDead
+	r.NextAt = nil
+	r.DeadAt = &now
+	r.LastError = err.Error()
+	r.UpdatedAt = now
+}
+
+func (s *eventService) GetRetries(ctx context.Context, status string, limit int64) ([]*Retry, error) {
+
+	switch status {
+	case "", retryPending, retryDelivered, retryDead:
+	default:
+		return nil, fmt.Errorf("invalid status: %s", status)
+	}
+
+	if limit <= 0 {
+		limit = defaultRetryLimit
+	}
+	if limit > maxRetryLimit {
+		limit = maxRetryLimit
+	}
+
+	return s.retryRepository.Find(ctx, status, limit)
+}
+
+func (s *eventService) ReplayRetry(ctx context.Context, id string) error {
+
+	if id == "" {
+		return errors.New("retry_id is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	replayed, err := s.retryRepository.Replay(ctx, objID, time.Now().Truncate(time.Millisecond))
+	if err != nil {
+		return err
+	}
+
+	if !replayed {
+		return errors.New("dead-lettered retry not found")
+	}
+
+	return nil
+}
+
+func (s *eventService) ReplayDeadRetries(ctx context.Context) (int64, error) {
+	return s.retryRepository.ReplayDead(ctx, time.Now().Truncate(time.Millisecond))
+}
@@ -0,0 +1,184 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deliveredRetryTTL is how long retries that eventually went through are
+// kept before Mongo expires them. Dead-lettered retries are kept until
+// replayed.
+const deliveredRetryTTL = 7 * 24 * time.Hour
+
+type RetryRepository interface {
+	Create(ctx context.Context, retry *Retry) error
+	FindDue(ctx context.Context, now time.Time, limit int64) ([]*Retry, error)
+	Update(ctx context.Context, retry *Retry, prevNextAt time.Time) (bool, error)
+	Find(ctx context.Context, status string, limit int64) ([]*Retry, error)
+	Replay(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error)
+	ReplayDead(ctx context.Context, now time.Time) (int64, error)
+}
+
+type retryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRetryRepository(collection *mongo.Collection) (RetryRepository, error) {
+	if err := EnsureRetryIndexes(context.Background(), collection); err != nil {
+		return nil, err
+	}
+	return &retryRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *retryRepository) Create(ctx context.Context, retry *Retry) error {
+
+	_, err := r.collection.InsertOne(ctx, retry)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindDue returns pending retries whose next attempt is due, oldest first.
+func (r *retryRepository) FindDue(ctx context.Context, now time.Time, limit int64) ([]*Retry, error) {
+
+	var retries []*Retry
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "next_at", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"next_at": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &retries)
+	if err != nil {
+		return nil, err
+	}
+
+	return retries, nil
+}
+
+// Update saves the retry only if it is still scheduled at prevNextAt, so an
+// attempt is never made twice. It reports whether the write won.
+func (r *retryRepository) Update(ctx context.Context, retry *Retry, prevNextAt time.Time) (bool, error) {
+
+	res, err := r.collection.ReplaceOne(ctx,
+		bson.M{
+			"_id":     retry.ID,
+			"next_at": prevNextAt,
+		},
+		retry,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount == 1, nil
+}
+
+// Find returns retries with the given status, or all when status is empty,
+// most recently updated first.
+func (r *retryRepository) Find(ctx context.Context, status string, limit int64) ([]*Retry, error) {
+
+	var retries []*Retry
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &retries)
+	if err != nil {
+		return nil, err
+	}
+
+	return retries, nil
+}
+
+// Replay puts a dead-lettered retry back in the queue with a fresh attempt
+// budget. It reports false when no dead retry has that id.
+func (r *retryRepository) Replay(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+
+	res, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": retryDead},
+		replayUpdate(now),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount == 1, nil
+}
+
+func (r *retryRepository) ReplayDead(ctx context.Context, now time.Time) (int64, error) {
+
+	res, err := r.collection.UpdateMany(ctx,
+		bson.M{"status": retryDead},
+		replayUpdate(now),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+func replayUpdate(now time.Time) bson.M {
+	return bson.M{
+		"$set": bson.M{
+			"status":     retryPending,
+			"attempts":   0,
+			"next_at":    now,
+			"updated_at": now,
+		},
+		"$unset": bson.M{"dead_at": ""},
+	}
+}
+
+func EnsureRetryIndexes(ctx context.Context, coll *mongo.Collection) error {
+
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "next_at", Value: 1}},
+			Options: options.Index().
+				SetName("by_next_at").
+				SetSparse(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "updated_at", Value: -1},
+			},
+			Options: options.Index().
+				SetName("by_status_updated"),
+		},
+		{
+			Keys: bson.D{{Key: "delivered_at", Value: 1}},
+			Options: options.Index().
+				SetName("delivered_ttl").
+				SetExpireAfterSeconds(int32(deliveredRetryTTL.Seconds())),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
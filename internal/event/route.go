@@ -6,16 +6,38 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, handler *EventHandler) {
+// RegisterRoutes mounts the event API. admin guards the operator endpoints
+// on top of authentication.
+func RegisterRoutes(r *gin.Engine, handler *EventHandler, admin gin.HandlerFunc) {
 	eventGroup := r.Group("api/v1/events", middleware.Secured())
 	{
 		eventGroup.POST("", handler.CreateEvent)
 		eventGroup.GET("", handler.GetAllEvents)
 		eventGroup.GET("/:id", handler.GetEventByID)
+		eventGroup.GET("/:id/occurrences", handler.GetEventOccurrences)
+		eventGroup.PUT("/:id/occurrences/:date", handler.OverrideOccurrence)
+		eventGroup.DELETE("/:id/occurrences/:date", handler.CancelOccurrence)
+		eventGroup.POST("/:id/snooze", handler.SnoozeEvent)
+		eventGroup.POST("/:id/acknowledge", handler.AcknowledgeOccurrence)
+		eventGroup.GET("/:id/deliveries", handler.GetDeliveries)
 		eventGroup.PUT("/:id", handler.UpdateEvent)
 		eventGroup.DELETE("/:id", handler.DeleteEvent)
 		eventGroup.PUT("/toggle-send/:id", handler.ToggleSendEventNotifications)
 		eventGroup.PUT("/toggle-show/:id", handler.ToggleShowEventNotifications)
 		eventGroup.POST("/trigger", handler.SendEventNotifications)
+		eventGroup.POST("/import", handler.ImportEvents)
+	}
+
+	calendarGroup := r.Group("api/v1/calendar", middleware.Secured())
+	{
+		calendarGroup.GET("", handler.GetCalendar)
+		calendarGroup.GET("/export.ics", handler.ExportCalendar)
+	}
+
+	retryGroup := r.Group("api/v1/admin/retries", middleware.Secured(), admin)
+	{
+		retryGroup.GET("", handler.GetRetries)
+		retryGroup.POST("/replay", handler.ReplayDeadRetries)
+		retryGroup.POST("/:id/replay", handler.ReplayRetry)
 	}
 }
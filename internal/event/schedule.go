@@ -0,0 +1,186 @@
+package event
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// The next reminder is searched for in nextFireSearchWindow steps up to
+// nextFireHorizon ahead. When nothing fires within the horizon but the event
+// goes on, next_fire_at is set to the horizon so the cron looks again then.
+const (
+	nextFireSearchWindow = 31 * 24 * time.Hour
+	nextFireHorizon      = 400 * 24 * time.Hour
+)
+
+// schedulerName keys the cron's persisted state.
+const schedulerName = "event-cron"
+
+// replayFrom returns the first minute the tick at now has to process: the
+// one after the last finished tick, so reminders missed during downtime are
+// sent late, but no more than maxLateness ago.
+func (s *eventService) replayFrom(ctx context.Context, now time.Time) time.Time {
+
+	last, err := s.stateRepository.GetLastTick(ctx, schedulerName)
+	if err != nil {
+		log.Printf("❌ Error GetLastTick: %v", err)
+		return now
+	}
+
+	if last.IsZero() {
+		return now
+	}
+
+	from := last.In(now.Location()).Truncate(time.Minute).Add(time.Minute)
+	if from.After(now) {
+		return now
+	}
+
+	floor := now.Add(-s.maxLateness).Truncate(time.Minute)
+	if from.Before(floor) {
+		log.Printf("⚠️ Dropping reminders due between %s and %s: later than max lateness %s",
+			from.Format("2006-01-02 15:04"), floor.Format("2006-01-02 15:04"), s.maxLateness)
+		from = floor
+	}
+
+	if from.Before(now) {
+		log.Printf("⏪ Replaying missed ticks since %s", from.Format("2006-01-02 15:04"))
+	}
+
+	return from
+}
+
+// nextFireAt returns the first minute at or after `after` at which
+// dueReminders returns something for ev, or nil when it never fires again.
+func (s *eventService) nextFireAt(ev *Event, after time.Time) *time.Time {
+
+	if !ev.IsSend || !ev.IsShow || ev.Schedule.Expiration <= 0 {
+		return nil
+	}
+
+	loc := s.eventLocation(ev)
+	after = after.In(loc)
+
+	end := ev.EndDate.In(loc)
+	if after.After(end) {
+		return nil
+	}
+
+	repeats := ev.Schedule.Expiration
+	spread := time.Duration(repeats) * time.Minute
+
+	var next *time.Time
+
+	for _, rule := range ev.Reminders {
+
+		if !rule.Enable {
+			continue
+		}
+
+		for offset := time.Duration(0); offset < nextFireHorizon; offset += nextFireSearchWindow {
+			from := s.addOffset(after.Add(offset), rule).Add(-reminderSearchMargin - spread)
+			if from.After(end) {
+				break
+			}
+			to := s.addOffset(after.Add(offset+nextFireSearchWindow), rule).Add(reminderSearchMargin)
+
+			occs, err := s.occurrences(ev, from, to)
+			if err != nil {
+				log.Printf("⛔ Invalid rrule %q: %v", ev.RRule, err)
+				return nil
+			}
+
+			found := false
+			for _, occ := range occs {
+				if ev.isAcknowledged(occ.Start) {
+					continue
+				}
+
+				fire := firstRepeatAtOrAfter(s.reminderFireTime(ev, occ.Start, rule), after, repeats)
+				if fire == nil || fire.After(end) {
+					continue
+				}
+
+				found = true
+				if next == nil || fire.Before(*next) {
+					next = fire
+				}
+			}
+
+			if found {
+				break
+			}
+		}
+	}
+
+	if next == nil {
+		horizon := after.Add(nextFireHorizon).Truncate(time.Minute)
+		if horizon.Before(end) {
+			return &horizon
+		}
+	}
+
+	return next
+}
+
+// firstRepeatAtOrAfter returns the first of the repeats sends of a reminder
+// firing at fire (one per minute) that is not before after.
+func firstRepeatAtOrAfter(fire time.Time, after time.Time, repeats int) *time.Time {
+
+	if !fire.Before(after) {
+		return &fire
+	}
+
+	k := int((after.Sub(fire) + time.Minute - 1) / time.Minute)
+	if k >= repeats {
+		return nil
+	}
+
+	t := fire.Add(time.Duration(k) * time.Minute)
+	return &t
+}
+
+// setNextFireAt records when e fires next, or that it never does.
+func (e *Event) setNextFireAt(next *time.Time) {
+	e.NextFireAt = next
+	e.ScheduleDone = next == nil
+}
+
+// scheduleNext stores when ev fires next after a cron tick. The write is
+// skipped if the event changed meanwhile, since that save already stored a
+// fresher value.
+func (s *eventService) scheduleNext(ctx context.Context, ev *Event, after time.Time) {
+
+	next := s.nextFireAt(ev, after)
+
+	if err := s.eventRepository.SetNextFireAt(ctx, ev.ID, next, ev.UpdatedAt); err != nil {
+		log.Printf("❌ Error SetNextFireAt for event %s: %v", ev.EventName, err)
+	}
+}
+
+// BackfillNextFireAt computes next_fire_at for every active event, for
+// documents saved before it was maintained. It returns how many events were
+// processed.
+func BackfillNextFireAt(ctx context.Context, repo EventRepository) (int, error) {
+
+	s := &eventService{
+		eventRepository: repo,
+		location:        defaultLocation(),
+	}
+
+	events, err := repo.FindEventActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for i, ev := range events {
+		next := s.nextFireAt(ev, now)
+		if err := repo.SetNextFireAt(ctx, ev.ID, next, ev.UpdatedAt); err != nil {
+			return i, err
+		}
+	}
+
+	return len(events), nil
+}
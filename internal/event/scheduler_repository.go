@@ -0,0 +1,61 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SchedulerStateRepository interface {
+	GetLastTick(ctx context.Context, name string) (time.Time, error)
+	SetLastTick(ctx context.Context, name string, tick time.Time) error
+}
+
+type schedulerStateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSchedulerStateRepository(collection *mongo.Collection) SchedulerStateRepository {
+	return &schedulerStateRepository{
+		collection: collection,
+	}
+}
+
+// GetLastTick returns the last tick the scheduler finished, or the zero time
+// if it never ran.
+func (r *schedulerStateRepository) GetLastTick(ctx context.Context, name string) (time.Time, error) {
+
+	var state SchedulerState
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&state)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	return state.LastTick, nil
+}
+
+// SetLastTick moves the last tick forward; an older tick is ignored.
+func (r *schedulerStateRepository) SetLastTick(ctx context.Context, name string, tick time.Time) error {
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "last_tick": bson.M{"$lt": tick}},
+		bson.M{"$set": bson.M{"last_tick": tick, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		// The upsert collides with a state that is already newer.
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
@@ -1,17 +1,23 @@
 package event
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 
+	"event-service/internal/leader"
+	"event-service/internal/notifier"
 	"event-service/internal/user"
+	"event-service/pkg/ical"
 
-	firebase "firebase.google.com/go/v4"
-	"firebase.google.com/go/v4/messaging"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -25,26 +31,66 @@ type EventService interface {
 	ToggleShowEventNotifications(ctx context.Context, id string) (string, error)
 	CronEventNotifications(ctx context.Context) error
 	SendEventNotifications(ctx context.Context, req *TriggerEventRequest) error
+	GetEventOccurrences(ctx context.Context, id string, from string, to string) ([]*OccurrenceResponse, error)
+	GetCalendar(ctx context.Context, userID string, from string, to string, timeZone string) ([]*CalendarItemResponse, error)
+	OverrideOccurrence(ctx context.Context, id string, date string, req *OccurrenceOverrideRequest) error
+	CancelOccurrence(ctx context.Context, id string, date string) error
+	ExportCalendar(ctx context.Context, userID string) ([]byte, error)
+	ImportEvents(ctx context.Context, userID string, timeZone string, r io.Reader) (*ImportResultResponse, error)
+	SnoozeEvent(ctx context.Context, id string, req *SnoozeRequest) (*SnoozeResponse, error)
+	AcknowledgeOccurrence(ctx context.Context, id string, userID string, req *AcknowledgeRequest) error
+	GetDeliveries(ctx context.Context, id string, limit int64) ([]*Delivery, error)
+	GetRetries(ctx context.Context, status string, limit int64) ([]*Retry, error)
+	ReplayRetry(ctx context.Context, id string) error
+	ReplayDeadRetries(ctx context.Context) (int64, error)
 }
 
+// maxOccurrenceWindow bounds how far a single occurrence listing may reach.
+const maxOccurrenceWindow = 366 * 24 * time.Hour
+
 type eventService struct {
-	eventRepository EventRepository
-	fireBase        *firebase.App
-	userService     user.UserService
-	location        *time.Location
+	eventRepository      EventRepository
+	escalationRepository EscalationRepository
+	deliveryRepository   DeliveryRepository
+	dispatchRepository   DispatchRepository
+	stateRepository      SchedulerStateRepository
+	retryRepository      RetryRepository
+	fence                leader.Fence
+	notifiers            *notifier.Registry
+	userService          user.UserService
+	location             *time.Location
+	instance             string
+	maxLateness          time.Duration
+	defaultLocale        string
+	profiles             *profileCache
+}
+
+func NewEventService(repo EventRepository, escalations EscalationRepository, deliveries DeliveryRepository, dispatches DispatchRepository, state SchedulerStateRepository, retries RetryRepository, fence leader.Fence, notifiers *notifier.Registry, us user.UserService, maxLateness time.Duration, defaultLocale string) EventService {
+	return &eventService{
+		eventRepository:      repo,
+		escalationRepository: escalations,
+		deliveryRepository:   deliveries,
+		dispatchRepository:   dispatches,
+		stateRepository:      state,
+		retryRepository:      retries,
+		fence:                fence,
+		notifiers:            notifiers,
+		userService:          us,
+		location:             defaultLocation(),
+		instance:             leader.InstanceName(),
+		maxLateness:          maxLateness,
+		defaultLocale:        normalizeLocale(defaultLocale),
+		profiles:             newProfileCache(),
+	}
 }
 
-func NewEventService(repo EventRepository, fb *firebase.App, us user.UserService) EventService {
+// defaultLocation is the zone of events saved without a time_zone.
+func defaultLocation() *time.Location {
 	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
 	if err != nil {
-		loc = time.UTC
-	}
-	return &eventService{
-		eventRepository: repo,
-		fireBase:        fb,
-		userService:     us,
-		location:        loc,
+		return time.UTC
 	}
+	return loc
 }
 
 func (s *eventService) CreateEvent(ctx context.Context, req *CreateEventRequest) error {
@@ -57,12 +103,17 @@ func (s *eventService) CreateEvent(ctx context.Context, req *CreateEventRequest)
 		return errors.New("start_date and end_date are required")
 	}
 
-	start, err := time.ParseInLocation("2006-01-02 15:04:05", req.StartDate, s.location)
+	loc, err := s.resolveLocation(req.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	start, err := parseEventDate(req.StartDate, loc, req.AllDay, false)
 	if err != nil {
 		return fmt.Errorf("invalid start_date: %w", err)
 	}
 
-	end, err := time.ParseInLocation("2006-01-02 15:04:05", req.EndDate, s.location)
+	end, err := parseEventDate(req.EndDate, loc, req.AllDay, true)
 	if err != nil {
 		return fmt.Errorf("invalid end_date: %w", err)
 	}
@@ -75,16 +126,48 @@ func (s *eventService) CreateEvent(ctx context.Context, req *CreateEventRequest)
 		req.Schedule.Expiration = 0
 	}
 
+	if req.DurationMinutes < 0 {
+		return errors.New("duration_minutes must not be negative")
+	}
+
+	if req.DurationDays < 0 {
+		return errors.New("duration_days must not be negative")
+	}
+
+	if err := s.validateReminders(req.Reminders); err != nil {
+		return err
+	}
+
+	rrule, err := s.normalizeRRule(req.RRule, loc)
+	if err != nil {
+		return err
+	}
+
+	locale, err := validateLocale(req.Locale)
+	if err != nil {
+		return err
+	}
+
+	if err := validateURL(req.Url); err != nil {
+		return err
+	}
+
 	ev := &Event{
 		ID:               primitive.NewObjectID(),
 		UserID:           req.UserID,
 		EventName:        req.EventName,
 		IsShow:           req.IsShow,
-		StartDate:        start.In(s.location),
-		EndDate:          end.In(s.location),
+		StartDate:        start,
+		EndDate:          end,
+		TimeZone:         loc.String(),
+		Locale:           locale,
 		IsSend:           true,
 		Reminders:        req.Reminders,
 		Schedule:         req.Schedule,
+		RRule:            rrule,
+		DurationMinutes:  req.DurationMinutes,
+		AllDay:           req.AllDay,
+		DurationDays:     req.DurationDays,
 		Note:             req.Note,
 		SoundKey:         req.SoundKey,
 		SoundRepeatTimes: req.SoundRepeatTimes,
@@ -93,6 +176,7 @@ func (s *eventService) CreateEvent(ctx context.Context, req *CreateEventRequest)
 		CreatedAt:        time.Now(),
 		UpdatedAt:        time.Now(),
 	}
+	ev.setNextFireAt(s.nextFireAt(ev, ev.CreatedAt))
 
 	return s.eventRepository.Create(ctx, ev)
 }
@@ -117,20 +201,58 @@ func (s *eventService) UpdateEvent(ctx context.Context, req *UpdateEventRequest,
 		ev.EventName = *req.EventName
 	}
 
+	loc := s.eventLocation(ev)
+
+	if req.TimeZone != nil {
+		newLoc, err := s.resolveLocation(*req.TimeZone)
+		if err != nil {
+			return err
+		}
+		// Moving an event to another zone keeps the wall-clock times the user
+		// picked, so a 07:00 reminder stays at 07:00 local time.
+		ev.StartDate = wallClockIn(ev.StartDate.In(loc), newLoc)
+		ev.EndDate = wallClockIn(ev.EndDate.In(loc), newLoc)
+		ev.TimeZone = newLoc.String()
+		loc = newLoc
+	}
+
+	if req.Locale != nil {
+		locale, err := validateLocale(*req.Locale)
+		if err != nil {
+			return err
+		}
+		ev.Locale = locale
+	}
+
+	if req.AllDay != nil && *req.AllDay != ev.AllDay {
+		ev.AllDay = *req.AllDay
+		if ev.AllDay {
+			ev.StartDate = startOfDay(ev.StartDate.In(loc))
+			ev.EndDate = startOfDay(ev.EndDate.In(loc)).AddDate(0, 0, 1).Add(-time.Second)
+		}
+	}
+
 	if req.StartDate != nil {
-		t, err := time.ParseInLocation("2006-01-02 15:04:05", *req.StartDate, s.location)
+		t, err := parseEventDate(*req.StartDate, loc, ev.AllDay, false)
 		if err != nil {
 			return fmt.Errorf("invalid start_date: %w", err)
 		}
-		ev.StartDate = t.In(s.location)
+		ev.StartDate = t
 	}
 
 	if req.EndDate != nil {
-		t, err := time.ParseInLocation("2006-01-02 15:04:05", *req.EndDate, s.location)
+		t, err := parseEventDate(*req.EndDate, loc, ev.AllDay, true)
 		if err != nil {
 			return fmt.Errorf("invalid end_date: %w", err)
 		}
-		ev.EndDate = t.In(s.location)
+		ev.EndDate = t
+	}
+
+	if req.DurationDays != nil {
+		if *req.DurationDays < 0 {
+			return errors.New("duration_days must not be negative")
+		}
+		ev.DurationDays = *req.DurationDays
 	}
 
 	if req.IsShow != nil {
@@ -142,6 +264,9 @@ func (s *eventService) UpdateEvent(ctx context.Context, req *UpdateEventRequest,
 	}
 
 	if req.Reminders != nil {
+		if err := s.validateReminders(*req.Reminders); err != nil {
+			return err
+		}
 		ev.Reminders = *req.Reminders
 	}
 
@@ -152,6 +277,21 @@ func (s *eventService) UpdateEvent(ctx context.Context, req *UpdateEventRequest,
 		}
 	}
 
+	if req.RRule != nil {
+		rrule, err := s.normalizeRRule(*req.RRule, loc)
+		if err != nil {
+			return err
+		}
+		ev.RRule = rrule
+	}
+
+	if req.DurationMinutes != nil {
+		if *req.DurationMinutes < 0 {
+			return errors.New("duration_minutes must not be negative")
+		}
+		ev.DurationMinutes = *req.DurationMinutes
+	}
+
 	if req.Note != nil {
 		ev.Note = *req.Note
 	}
@@ -166,6 +306,9 @@ func (s *eventService) UpdateEvent(ctx context.Context, req *UpdateEventRequest,
 	}
 
 	if req.Url != nil {
+		if err := validateURL(*req.Url); err != nil {
+			return err
+		}
 		ev.Url = *req.Url
 	}
 
@@ -174,6 +317,7 @@ func (s *eventService) UpdateEvent(ctx context.Context, req *UpdateEventRequest,
 	}
 
 	ev.UpdatedAt = time.Now()
+	ev.setNextFireAt(s.nextFireAt(ev, ev.UpdatedAt))
 
 	return s.eventRepository.UpdateEvent(ctx, ev, objID)
 
@@ -183,19 +327,22 @@ func (s *eventService) CronEventNotifications(ctx context.Context) error {
 
 	now := time.Now().In(s.location).Truncate(time.Minute)
 
-	log.Printf("🕐 Cron check at: %s", now.Format("2006-01-02 15:04:05"))
+	log.Printf("🕐 Cron check at: %s", now.Format("2006-01-02 15:04:05 MST"))
 
-	events, err := s.eventRepository.FindEventActive(ctx)
+	events, err := s.eventRepository.FindDueEvents(ctx, now)
 	if err != nil {
-		log.Printf("❌ Error FindEventActive: %v", err)
+		log.Printf("❌ Error FindDueEvents: %v", err)
 		return err
 	}
 
-	log.Printf("📋 Found %d active events", len(events))
+	log.Printf("📋 Found %d due events", len(events))
+
+	from := s.replayFrom(ctx, now)
 
 	for _, ev := range events {
-		start := ev.StartDate.In(s.location)
-		end := ev.EndDate.In(s.location)
+		loc := s.eventLocation(ev)
+		start := ev.StartDate.In(loc)
+		end := ev.EndDate.In(loc)
 		log.Printf("➡️ Checking event %s (Start=%s, End=%s, Expiration=%d, StartHHmm=%02d:%02d)",
 			ev.EventName,
 			start.Format("2006-01-02 15:04:05"),
@@ -204,49 +351,112 @@ func (s *eventService) CronEventNotifications(ctx context.Context) error {
 			start.Hour(), start.Minute(),
 		)
 
-		if s.shouldSendNotification(ev, now) {
+		// Replay every minute since the last finished tick, starting no
+		// earlier than the event's next reminder.
+		tick := from
+		if ev.NextFireAt != nil && ev.NextFireAt.After(tick) {
+			tick = ev.NextFireAt.In(s.location).Truncate(time.Minute)
+		}
+
+		triggered := false
+		for ; !tick.After(now); tick = tick.Add(time.Minute) {
+			due := s.dueReminders(ev, tick)
+			if len(due) == 0 {
+				continue
+			}
+
+			// Only the leader runs this cron, but a deposed leader's tick can
+			// overlap its successor's; only the one that claims a reminder in
+			// the dispatch ledger under a current lease sends it.
+			due = s.claimDispatches(ctx, ev, due)
+			if len(due) == 0 {
+				continue
+			}
+
+			triggered = true
+			late := tick.Before(now)
+			if late {
+				log.Printf("⏰ Late reminder for event %s (scheduled %s)", ev.EventName, tick.Format("2006-01-02 15:04:05"))
+			}
+
 			log.Printf("✅ Triggered event: %s", ev.EventName)
-			s.sendNotification(ctx, ev)
-		} else {
+			s.startEscalations(ctx, ev, due)
+			for _, d := range due {
+				s.sendNotification(ctx, ev, reminderNotice{
+					Trigger:     triggerReminder,
+					RuleIndex:   &d.RuleIndex,
+					Channels:    ev.Reminders[d.RuleIndex].Channels,
+					Occurrence:  d.Occurrence.Start,
+					Late:        late,
+					ScheduledAt: tick,
+				})
+			}
+		}
+
+		if !triggered {
 			log.Printf("⏭️ Skipped event: %s", ev.EventName)
 		}
+
+		s.scheduleNext(ctx, ev, now.Add(time.Minute))
+	}
+
+	s.deliverSnoozes(ctx, now)
+	s.processEscalations(ctx, now)
+	s.processRetries(ctx, now)
+
+	if err := s.stateRepository.SetLastTick(ctx, schedulerName, now); err != nil {
+		log.Printf("❌ Error SetLastTick: %v", err)
 	}
 
 	return nil
 }
 
-func (s *eventService) shouldSendNotification(ev *Event, now time.Time) bool {
+// dueReminder is one reminder the cron delivers at the current tick.
+type dueReminder struct {
+	Occurrence *OccurrenceResponse
+	RuleIndex  int
+	Attempt    int
+	FireAt     time.Time
+}
+
+// reminderSearchMargin widens the occurrence lookup around now+offset. It
+// covers all-day reminders at a local time of day and month offsets, which
+// addOffset and subtractOffset do not invert exactly.
+const reminderSearchMargin = 4 * 24 * time.Hour
+
+// dueReminders returns the reminders that fire at now. A rule fires at its
+// computed time and then once a minute for Expiration minutes in total.
+func (s *eventService) dueReminders(ev *Event, now time.Time) []dueReminder {
 
-	log.Printf("🔍 shouldSendNotification: now=%s", now.Format("2006-01-02 15:04:05"))
+	log.Printf("🔍 dueReminders: now=%s", now.Format("2006-01-02 15:04:05"))
 
 	if !ev.IsSend || !ev.IsShow {
 		log.Printf("⛔ Event disabled (IsSend=%t, IsShow=%t)", ev.IsSend, ev.IsShow)
-		return false
+		return nil
 	}
 
-	start := ev.StartDate.In(s.location)
-	end := ev.EndDate.In(s.location)
+	// Reminder offsets are applied in the event's own zone so "1 day before"
+	// means the same local time on the previous day, even across DST.
+	loc := s.eventLocation(ev)
+	now = now.In(loc)
+
+	end := ev.EndDate.In(loc)
 
 	if now.After(end) {
 		log.Printf("⛔ now > EndDate: now=%s, End=%s",
 			now.Format("2006-01-02 15:04:05"),
 			end.Format("2006-01-02 15:04:05"))
-		return false
+		return nil
 	}
 
-	exp := ev.Schedule.Expiration
-	if exp < 0 {
-		exp = 0
-	}
-
-	repeats := exp
-	if repeats <= 0  {
-		repeats = 0
+	repeats := ev.Schedule.Expiration
+	if repeats <= 0 {
+		return nil
 	}
 
 	interval := time.Minute
 
-	startHH, startMM := start.Hour(), start.Minute()
+	var due []dueReminder
 
 	for ridx, rule := range ev.Reminders {
 
@@ -255,48 +465,351 @@ func (s *eventService) shouldSendNotification(ev *Event, now time.Time) bool {
 			continue
 		}
 
-		for k := 0; k < repeats; k++ {
-
-			base := now.Add(-time.Duration(k) * interval)
-			occCandidate := s.addOffset(base, rule)
+		probe := s.addOffset(now, rule)
+		from := probe.Add(-reminderSearchMargin - time.Duration(repeats)*interval)
+		to := probe.Add(reminderSearchMargin)
 
-			occ := time.Date(occCandidate.Year(), occCandidate.Month(), occCandidate.Day(),
-				startHH, startMM, 0, 0, s.location)
-
-			log.Printf("🧮 Rule %d, k=%d -> occ=%s (weekday=%s); window=[%s..%s]",
-				ridx, k,
-				occ.Format("2006-01-02 15:04:05"),
-				occ.Weekday().String(),
-				start.Format("2006-01-02 15:04:05"),
-				end.Format("2006-01-02 15:04:05"),
-			)
+		occs, err := s.occurrences(ev, from, to)
+		if err != nil {
+			log.Printf("⛔ Invalid rrule %q: %v", ev.RRule, err)
+			return nil
+		}
 
-			if occ.Before(start) || occ.After(end) {
-				log.Printf("   ⛔ occ out of range")
+		for _, occ := range occs {
+			if ev.isAcknowledged(occ.Start) {
 				continue
 			}
 
-			if len(ev.Schedule.Day) > 0 && !s.weekdayAllowed(occ.Weekday(), ev.Schedule.Day) {
-				log.Printf("   ⛔ occ weekday %s not allowed by Day selection", occ.Weekday())
-				continue
+			fire := s.reminderFireTime(ev, occ.Start, rule)
+
+			for k := 0; k < repeats; k++ {
+				candidate := fire.Add(time.Duration(k) * interval)
+				if !now.Equal(candidate) {
+					continue
+				}
+
+				log.Printf("🎯 EXACT MATCH → send (rule=%d, k=%d, occ=%s)", ridx, k, occ.Start.Format("2006-01-02 15:04:05"))
+				due = append(due, dueReminder{
+					Occurrence: occ,
+					RuleIndex:  ridx,
+					Attempt:    k,
+					FireAt:     fire,
+				})
 			}
+		}
+	}
+
+	return due
+}
+
+func (s *eventService) GetEventOccurrences(ctx context.Context, id string, from string, to string) ([]*OccurrenceResponse, error) {
+
+	if id == "" {
+		return nil, errors.New("event_id is required")
+	}
+
+	if from == "" || to == "" {
+		return nil, errors.New("from and to are required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ev, err := s.eventRepository.FindEventByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ev == nil {
+		return nil, errors.New("event not found")
+	}
+
+	loc := s.eventLocation(ev)
 
-			target := s.subtractOffset(occ, rule).Truncate(time.Minute)
-			candidate := target.Add(time.Duration(k) * interval) 
-			log.Printf("🎛️ Check rule=%d k=%d: target=%s | candidate=%s | now=%s",
-				ridx, k,
-				target.Format("2006-01-02 15:04:05"),
-				candidate.Format("2006-01-02 15:04:05"),
-				now.Format("2006-01-02 15:04:05"),
-			)
-
-			if now.Equal(candidate) {
-				log.Printf("🎯 EXACT MATCH → send (rule=%d, k=%d)", ridx, k)
-				return true
+	fromTime, err := parseQueryTime(from, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+
+	toTime, err := parseQueryTime(to, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+
+	if toTime.Before(fromTime) {
+		return nil, errors.New("to must be after from")
+	}
+
+	if toTime.Sub(fromTime) > maxOccurrenceWindow {
+		return nil, fmt.Errorf("window must not exceed %d days", int(maxOccurrenceWindow.Hours()/24))
+	}
+
+	return s.expandOccurrences(ev, fromTime, toTime)
+}
+
+func (s *eventService) GetCalendar(ctx context.Context, userID string, from string, to string, timeZone string) ([]*CalendarItemResponse, error) {
+
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+
+	if from == "" || to == "" {
+		return nil, errors.New("from and to are required")
+	}
+
+	loc, err := s.resolveLocation(timeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	fromTime, err := parseQueryTime(from, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+
+	toTime, err := parseQueryTime(to, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+
+	if toTime.Before(fromTime) {
+		return nil, errors.New("to must be after from")
+	}
+
+	if toTime.Sub(fromTime) > maxOccurrenceWindow {
+		return nil, fmt.Errorf("window must not exceed %d days", int(maxOccurrenceWindow.Hours()/24))
+	}
+
+	events, err := s.eventRepository.FindAllEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*CalendarItemResponse, 0)
+
+	for _, ev := range events {
+		occurrences, err := s.expandOccurrences(ev, fromTime, toTime)
+		if err != nil {
+			log.Printf("⛔ Skipping event %s in calendar: %v", ev.ID.Hex(), err)
+			continue
+		}
+
+		for _, occ := range occurrences {
+			items = append(items, &CalendarItemResponse{
+				EventID:   ev.ID.Hex(),
+				EventName: occ.EventName,
+				Icon:      ev.Icon,
+				TimeZone:  s.eventLocation(ev).String(),
+				AllDay:    ev.AllDay,
+				Start:     occ.Start,
+				End:       occ.End,
+				Reminders: s.reminderTimes(ev, occ.Start),
+			})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].Start.Equal(items[j].Start) {
+			return items[i].Start.Before(items[j].Start)
+		}
+		return items[i].EventName < items[j].EventName
+	})
+
+	return items, nil
+}
+
+func (s *eventService) OverrideOccurrence(ctx context.Context, id string, date string, req *OccurrenceOverrideRequest) error {
+
+	if id == "" {
+		return errors.New("event_id is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	ev, err := s.eventRepository.FindEventByID(ctx, objID)
+	if err != nil || ev == nil {
+		return errors.New("event not found")
+	}
+
+	original, err := s.occurrenceOn(ev, date)
+	if err != nil {
+		return err
+	}
+
+	loc := s.eventLocation(ev)
+
+	exc := OccurrenceException{
+		OriginalStart: original,
+		EventName:     req.EventName,
+		Note:          req.Note,
+	}
+
+	if req.StartDate != nil {
+		t, err := parseEventDate(*req.StartDate, loc, ev.AllDay, false)
+		if err != nil {
+			return fmt.Errorf("invalid start_date: %w", err)
+		}
+		if t.Before(ev.StartDate) || t.After(ev.EndDate) {
+			return errors.New("start_date must be between the event's start_date and end_date")
+		}
+		exc.StartDate = &t
+	}
+
+	if req.DurationMinutes != nil {
+		if *req.DurationMinutes < 0 {
+			return errors.New("duration_minutes must not be negative")
+		}
+		exc.DurationMinutes = req.DurationMinutes
+	}
+
+	ev.setException(exc)
+	ev.UpdatedAt = time.Now()
+	ev.setNextFireAt(s.nextFireAt(ev, ev.UpdatedAt))
+
+	return s.eventRepository.UpdateEvent(ctx, ev, objID)
+}
+
+func (s *eventService) CancelOccurrence(ctx context.Context, id string, date string) error {
+
+	if id == "" {
+		return errors.New("event_id is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	ev, err := s.eventRepository.FindEventByID(ctx, objID)
+	if err != nil || ev == nil {
+		return errors.New("event not found")
+	}
+
+	original, err := s.occurrenceOn(ev, date)
+	if err != nil {
+		return err
+	}
+
+	ev.setException(OccurrenceException{
+		OriginalStart: original,
+		Cancelled:     true,
+	})
+	ev.UpdatedAt = time.Now()
+	ev.setNextFireAt(s.nextFireAt(ev, ev.UpdatedAt))
+
+	return s.eventRepository.UpdateEvent(ctx, ev, objID)
+}
+
+func (s *eventService) ExportCalendar(ctx context.Context, userID string) ([]byte, error) {
+
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+
+	events, err := s.eventRepository.FindAllEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, s.buildCalendar(events)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parseEventDate parses a start_date/end_date payload value. All-day events
+// take a bare date (any time of day is dropped) stored as local midnight, or
+// as the last second of the day for end dates so the event stays active
+// until its last day is over.
+func parseEventDate(value string, loc *time.Location, allDay bool, isEnd bool) (time.Time, error) {
+
+	if !allDay {
+		return time.ParseInLocation("2006-01-02 15:04:05", value, loc)
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		t, err = time.ParseInLocation("2006-01-02 15:04:05", value, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	day := startOfDay(t)
+	if isEnd {
+		return day.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+
+	return day, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// validateURL checks an event's link; empty means none. Only absolute http
+// and https URLs are accepted, and never with control characters, which
+// could break the line the URL is written on in exports.
+func validateURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if strings.ContainsFunc(raw, unicode.IsControl) {
+		return errors.New("invalid url: must not contain control characters")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid url: must be an http or https URL")
+	}
+	return nil
+}
+
+func (s *eventService) validateReminders(rules []ReminderRule) error {
+	for i, rule := range rules {
+		if rule.At == nil || *rule.At == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", *rule.At); err != nil {
+			return fmt.Errorf("invalid reminder_settings[%d].at: must be HH:MM", i)
+		}
+	}
+	for i, rule := range rules {
+		if err := validateEscalation(i, rule.Escalation); err != nil {
+			return err
+		}
+		if rule.Message != nil && *rule.Message != "" {
+			if _, err := parseMessageTemplate(*rule.Message); err != nil {
+				return fmt.Errorf("invalid reminder_settings[%d].message: %w", i, err)
+			}
+		}
+		for _, channel := range rule.Channels {
+			if _, ok := s.notifiers.Get(channel); !ok {
+				return fmt.Errorf("invalid reminder_settings[%d].channels: unknown channel %q", i, channel)
 			}
 		}
 	}
-	return false
+	return nil
+}
+
+func (s *eventService) normalizeRRule(value string, loc *time.Location) (string, error) {
+
+	if strings.TrimSpace(value) == "" {
+		return "", nil
+	}
+
+	rec, err := ParseRecurrence(value, loc)
+	if err != nil {
+		return "", fmt.Errorf("invalid rrule: %w", err)
+	}
+
+	return rec.String(), nil
 }
 
 func (s *eventService) subtractOffset(base time.Time, r ReminderRule) time.Time {
@@ -316,16 +829,6 @@ func (s *eventService) subtractOffset(base time.Time, r ReminderRule) time.Time
 	}
 }
 
-func (s *eventService) weekdayAllowed(wd time.Weekday, days []DayOption) bool {
-	key := strings.ToLower(wd.String())
-	for _, d := range days {
-		if strings.ToLower(d.Key) == key {
-			return true
-		}
-	}
-	return false
-}
-
 func (s *eventService) addOffset(base time.Time, r ReminderRule) time.Time {
 	switch r.ReminderBefore {
 	case "minutes":
@@ -343,55 +846,81 @@ func (s *eventService) addOffset(base time.Time, r ReminderRule) time.Time {
 	}
 }
 
-func (s *eventService) sendNotification(ctx context.Context, event *Event) {
+func (s *eventService) sendNotification(ctx context.Context, event *Event, notice reminderNotice) {
 
-	tokens, err := s.userService.GetTokenUser(ctx, event.UserID)
-	if err != nil || tokens == nil {
-		log.Printf("❌ GetTokenUser error for user %s: %v", event.UserID, err)
-		return
+	recipient := event.UserID
+	if notice.Recipient != "" {
+		recipient = notice.Recipient
 	}
 
-	if len(*tokens) == 0 {
-		log.Printf("📵 No tokens found for user %s", event.UserID)
-		return
+	msg := s.renderMessage(ctx, event, notice, recipient, time.Now())
+
+	zone := s.eventLocation(event).String()
+	localStart := msg.LocalStart
+	if !event.AllDay {
+		localStart += " (" + zone + ")"
 	}
 
-	message := s.getNotificationMessage(event)
+	n := &notifier.Notification{
+		ID:       primitive.NewObjectID().Hex(),
+		UserID:   recipient,
+		Title:    msg.Title,
+		Body:     msg.Body,
+		Category: reminderCategory,
+		Data:     notificationData(event, notice),
+		Push:     pushOptions(event, notice),
+		Subject: notifier.Subject{
+			EventID:    event.ID.Hex(),
+			EventName:  event.EventName,
+			Note:       event.Note,
+			URL:        event.Url,
+			TimeZone:   zone,
+			AllDay:     event.AllDay,
+			Occurrence: notice.Occurrence,
+			Trigger:    notice.Trigger,
+			Late:       notice.Late,
+			LocalStart: localStart,
+			Labels:     msg.Labels,
+		},
+	}
 
-	successCount := 0
-	for _, token := range *tokens {
-		if token == "" {
+	for _, channel := range reminderChannels(notice.Channels) {
+
+		nt, ok := s.notifiers.Get(channel)
+		if !ok {
+			log.Printf("❌ Unknown notification channel %q", channel)
+			s.recordDelivery(ctx, event, notice, recipient, channel, "", "", fmt.Errorf("unknown channel %q", channel))
 			continue
 		}
 
-		client, err := s.fireBase.Messaging(ctx)
+		results, err := nt.Notify(ctx, n)
 		if err != nil {
-			log.Printf("❌ Firebase client error: %v", err)
+			s.recordDelivery(ctx, event, notice, recipient, channel, "", "", err)
+			s.enqueueRetry(ctx, event, notice, n, channel, nt, notifier.Result{Err: err})
 			continue
 		}
 
-		msg := &messaging.Message{
-			Notification: &messaging.Notification{
-				Title: "🔔 " + event.EventName,
-				Body:  message,
-			},
-			Token: token,
+		successCount := 0
+		for _, r := range results {
+			s.recordDelivery(ctx, event, notice, recipient, channel, r.Target, r.MessageID, r.Err)
+			if r.Err == nil {
+				successCount++
+			} else {
+				s.enqueueRetry(ctx, event, notice, n, channel, nt, r)
+			}
 		}
 
-		response, err := client.Send(ctx, msg)
-		if err != nil {
-			log.Printf("❌ Failed to send to token %s: %v", token, err)
-		} else {
-			log.Printf("✅ Sent notification to token %s (response: %s)", token, response)
-			successCount++
-		}
+		log.Printf("📊 Event %s: sent %d/%d %s notifications successfully", event.EventName, successCount, len(results), channel)
 	}
-
-	log.Printf("📊 Event %s: sent %d/%d notifications successfully", event.EventName, successCount, len(*tokens))
 }
 
-func (s *eventService) getNotificationMessage(event *Event) string {
-	return fmt.Sprintf("Nhắc nhở: %s sắp bắt đầu!", event.EventName)
+// reminderChannels returns the channels a reminder goes out on; rules that
+// pick none use push notifications.
+func reminderChannels(channels []string) []string {
+	if len(channels) == 0 {
+		return []string{notifier.ChannelFCM}
+	}
+	return channels
 }
 
 func (s *eventService) GetAllEvents(ctx context.Context, userID string) ([]*Event, error) {
@@ -461,6 +990,8 @@ func (s *eventService) ToggleSendEventNotifications(ctx context.Context, id stri
 		event.IsSend = true
 		check = "on"
 	}
+	event.UpdatedAt = time.Now()
+	event.setNextFireAt(s.nextFireAt(event, event.UpdatedAt))
 
 	err = s.eventRepository.UpdateEvent(ctx, event, objectID)
 	if err != nil {
@@ -499,6 +1030,8 @@ func (s *eventService) ToggleShowEventNotifications(ctx context.Context, id stri
 		event.IsShow = true
 		check = "on"
 	}
+	event.UpdatedAt = time.Now()
+	event.setNextFireAt(s.nextFireAt(event, event.UpdatedAt))
 
 	err = s.eventRepository.UpdateEvent(ctx, event, objectID)
 	if err != nil {
@@ -528,7 +1061,13 @@ func (s *eventService) SendEventNotifications(ctx context.Context, req *TriggerE
 		return errors.New("event not found")
 	}
 
-	s.sendNotification(ctx, event)
+	notice := reminderNotice{Trigger: triggerManual}
+	occ, err := s.currentOccurrence(event, time.Now().In(s.eventLocation(event)))
+	if err == nil && occ != nil {
+		notice.Occurrence = occ.Start
+	}
+
+	s.sendNotification(ctx, event, notice)
 
 	return nil
 }
@@ -0,0 +1,28 @@
+package event
+
+import "testing"
+
+func TestValidateURL(t *testing.T) {
+
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: ""},
+		{url: "https://example.com/meeting?id=1"},
+		{url: "http://example.com"},
+		{url: "javascript:alert(1)", wantErr: true},
+		{url: "ftp://example.com/file", wantErr: true},
+		{url: "https://", wantErr: true},
+		{url: "/relative/path", wantErr: true},
+		{url: "https://example.com\r\nATTENDEE:mailto:x@example.com", wantErr: true},
+		{url: "https://example.com/\x00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := validateURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
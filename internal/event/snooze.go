@@ -0,0 +1,264 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// snoozeOptions are the snooze durations, in minutes, offered as action
+// buttons on a reminder push.
+var snoozeOptions = []int{5, 10, 30}
+
+// maxSnoozeAhead bounds how far into the future a reminder may be snoozed.
+const maxSnoozeAhead = 7 * 24 * time.Hour
+
+func (s *eventService) SnoozeEvent(ctx context.Context, id string, req *SnoozeRequest) (*SnoozeResponse, error) {
+
+	if id == "" {
+		return nil, errors.New("event_id is required")
+	}
+
+	hasUntil := req.Until != nil && *req.Until != ""
+	if req.Minutes < 0 {
+		return nil, errors.New("minutes must be positive")
+	}
+	if (req.Minutes > 0) == hasUntil {
+		return nil, errors.New("exactly one of minutes or until is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ev, err := s.eventRepository.FindEventByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ev == nil {
+		return nil, errors.New("event not found")
+	}
+
+	loc := s.eventLocation(ev)
+	now := time.Now().In(loc).Truncate(time.Minute)
+
+	remindAt := now.Add(time.Duration(req.Minutes) * time.Minute)
+	if hasUntil {
+		remindAt, err = parseQueryTime(*req.Until, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid until: %w", err)
+		}
+		if !remindAt.After(now) {
+			return nil, errors.New("until must be in the future")
+		}
+	}
+
+	if remindAt.Sub(now) > maxSnoozeAhead {
+		return nil, errors.New("snooze must not exceed 7 days")
+	}
+
+	occ, err := s.resolveOccurrence(ev, req.Occurrence, now)
+	if err != nil {
+		return nil, err
+	}
+
+	snooze := &Snooze{
+		ID:              primitive.NewObjectID(),
+		OccurrenceStart: occ.Start,
+		RemindAt:        remindAt,
+		CreatedAt:       time.Now(),
+	}
+
+	if err := s.eventRepository.SaveSnooze(ctx, objID, snooze); err != nil {
+		return nil, err
+	}
+
+	return &SnoozeResponse{
+		ID:              snooze.ID.Hex(),
+		EventID:         ev.ID.Hex(),
+		OccurrenceStart: snooze.OccurrenceStart,
+		RemindAt:        snooze.RemindAt,
+	}, nil
+}
+
+// resolveOccurrence returns the occurrence starting at value, as sent back by
+// a notification action, or the current occurrence when value is empty.
+func (s *eventService) resolveOccurrence(ev *Event, value *string, now time.Time) (*OccurrenceResponse, error) {
+
+	if value == nil || *value == "" {
+		occ, err := s.currentOccurrence(ev, now)
+		if err != nil {
+			return nil, err
+		}
+		if occ == nil {
+			return nil, errors.New("event has no current occurrence")
+		}
+		return occ, nil
+	}
+
+	start, err := parseQueryTime(*value, s.eventLocation(ev))
+	if err != nil {
+		return nil, fmt.Errorf("invalid occurrence: %w", err)
+	}
+
+	occs, err := s.occurrences(ev, start, start)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(occs) == 0 {
+		return nil, errors.New("occurrence not found")
+	}
+
+	return occs[0], nil
+}
+
+// currentOccurrence returns the occurrence a reminder sent around now refers
+// to: the one in progress or the next one to start, or failing that the most
+// recent one within maxSnoozeAhead.
+func (s *eventService) currentOccurrence(ev *Event, now time.Time) (*OccurrenceResponse, error) {
+
+	occs, err := s.occurrences(ev, now.Add(-maxSnoozeAhead), now.Add(maxSnoozeAhead))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, occ := range occs {
+		if !occ.End.Before(now) {
+			return occ, nil
+		}
+	}
+
+	if len(occs) == 0 {
+		return nil, nil
+	}
+
+	return occs[len(occs)-1], nil
+}
+
+// deliverSnoozes sends every snoozed reminder that is due at now. Snoozes do
+// not go through the reminder rules; each is delivered once and removed.
+func (s *eventService) deliverSnoozes(ctx context.Context, now time.Time) {
+
+	events, err := s.eventRepository.FindEventsWithDueSnoozes(ctx, now)
+	if err != nil {
+		log.Printf("❌ Error FindEventsWithDueSnoozes: %v", err)
+		return
+	}
+
+	for _, ev := range events {
+		for _, snooze := range ev.Snoozes {
+			if snooze.RemindAt.After(now) {
+				continue
+			}
+
+			var claimed bool
+			err := s.fenced(ctx, func(ctx context.Context) (err error) {
+				claimed, err = s.eventRepository.RemoveSnooze(ctx, ev.ID, snooze.ID)
+				return err
+			})
+			if err != nil {
+				log.Printf("❌ Error RemoveSnooze %s: %v", snooze.ID.Hex(), err)
+				continue
+			}
+
+			if !claimed {
+				continue
+			}
+
+			if !ev.IsSend || !ev.IsShow {
+				log.Printf("⛔ Dropped snooze for disabled event %s", ev.EventName)
+				continue
+			}
+
+			if now.Sub(snooze.RemindAt) > s.maxLateness {
+				log.Printf("⛔ Dropped snooze for event %s: later than max lateness", ev.EventName)
+				continue
+			}
+
+			log.Printf("😴 Snoozed reminder due: %s (occ=%s)",
+				ev.EventName,
+				snooze.OccurrenceStart.In(s.eventLocation(ev)).Format("2006-01-02 15:04:05"))
+
+			s.sendNotification(ctx, ev, reminderNotice{
+				Trigger:     triggerSnooze,
+				Occurrence:  snooze.OccurrenceStart,
+				Snoozed:     true,
+				Late:        snooze.RemindAt.Before(now),
+				ScheduledAt: snooze.RemindAt,
+			})
+		}
+	}
+}
+
+// reminderNotice describes what a push refers to, so the app's action
+// buttons can act on the right occurrence. Recipient overrides the event's
+// owner, e.g. for the backup user of an escalation.
+type reminderNotice struct {
+	Trigger    string
+	RuleIndex  *int
+	Channels   []string
+	Occurrence time.Time
+	Snoozed    bool
+	Recipient  string
+	Escalated  bool
+	Attempt    int
+	// Late marks a send that was due at ScheduledAt but only went out now,
+	// after a gap in the scheduler.
+	Late        bool
+	ScheduledAt time.Time
+}
+
+// reminderCategory is the notification category (APNs) and click action
+// (Android) the mobile apps register the snooze buttons under.
+const reminderCategory = "EVENT_REMINDER"
+
+// notificationData is the FCM data payload: the event and occurrence the app
+// opens, its alarm sound, and the snooze and acknowledge actions.
+func notificationData(ev *Event, notice reminderNotice) map[string]string {
+
+	data := map[string]string{
+		"type":           "event_reminder",
+		"event_id":       ev.ID.Hex(),
+		"snoozed":        strconv.FormatBool(notice.Snoozed),
+		"snooze_path":    fmt.Sprintf("/api/v1/events/%s/snooze", ev.ID.Hex()),
+		"snooze_options": joinInts(snoozeOptions),
+		"ack_path":       fmt.Sprintf("/api/v1/events/%s/acknowledge", ev.ID.Hex()),
+		"escalated":      strconv.FormatBool(notice.Escalated),
+	}
+
+	if ev.Url != "" {
+		data["deep_link"] = ev.Url
+	}
+
+	if ev.SoundKey != "" {
+		data["sound_key"] = ev.SoundKey
+		data["sound_repeat_times"] = strconv.FormatInt(ev.SoundRepeatTimes, 10)
+	}
+
+	if ev.Icon != "" {
+		data["icon"] = ev.Icon
+	}
+
+	if notice.Late {
+		data["late"] = "true"
+		data["scheduled_at"] = notice.ScheduledAt.UTC().Format(time.RFC3339)
+	}
+
+	if notice.Attempt > 0 {
+		data["attempt"] = strconv.Itoa(notice.Attempt)
+	}
+
+	if !notice.Occurrence.IsZero() {
+		data["occurrence"] = notice.Occurrence.UTC().Format(time.RFC3339)
+	}
+
+	return data
+}
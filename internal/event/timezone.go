@@ -0,0 +1,88 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// locationCache avoids re-reading the tz database for every event on every
+// cron tick.
+var locationCache sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// resolveLocation validates an IANA zone name from a request. An empty name
+// selects the service default zone.
+func (s *eventService) resolveLocation(name string) (*time.Location, error) {
+
+	if name == "" {
+		return s.location, nil
+	}
+
+	// "Local" depends on the host the pod happens to run on.
+	if name == "Local" {
+		return nil, errors.New("invalid time_zone: Local is not allowed")
+	}
+
+	loc, err := loadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time_zone: %w", err)
+	}
+
+	return loc, nil
+}
+
+// eventLocation returns the zone an event is scheduled in. Events created
+// before time_zone existed fall back to the service default zone.
+func (s *eventService) eventLocation(ev *Event) *time.Location {
+
+	if ev.TimeZone == "" {
+		return s.location
+	}
+
+	loc, err := loadLocation(ev.TimeZone)
+	if err != nil {
+		return s.location
+	}
+
+	return loc
+}
+
+// wallClockIn returns the instant showing the same wall-clock time as t in loc.
+func wallClockIn(t time.Time, loc *time.Location) time.Time {
+	return localTime(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// localTime is time.Date for wall-clock times that may fall in a DST gap.
+// time.Date does not say which side of the gap it resolves to; RFC 5545
+// interprets such a time with the offset from before the gap, which moves it
+// forward by the gap's length (02:30 on a spring-forward day becomes 03:30).
+func localTime(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) time.Time {
+
+	t := time.Date(year, month, day, hour, min, sec, nsec, loc)
+
+	if t.Hour() == hour && t.Minute() == min && t.Second() == sec {
+		return t
+	}
+
+	// The offset in force a few hours earlier is the one before the gap.
+	_, before := t.Add(-3 * time.Hour).Zone()
+	naive := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+
+	return naive.Add(-time.Duration(before) * time.Second).In(loc)
+}
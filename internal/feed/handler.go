@@ -0,0 +1,96 @@
+package feed
+
+import (
+	"errors"
+	"event-service/helper"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeedHandler struct {
+	feedService FeedService
+}
+
+func NewFeedHandler(feedService FeedService) *FeedHandler {
+	return &FeedHandler{
+		feedService: feedService,
+	}
+}
+
+func (h *FeedHandler) RotateToken(c *gin.Context) {
+
+	userID := helper.RequestUserID(c)
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, err := h.feedService.RotateToken(c, userID)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusCreated, "Rotate feed token successfully", token)
+}
+
+func (h *FeedHandler) RevokeToken(c *gin.Context) {
+
+	userID := helper.RequestUserID(c)
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	err := h.feedService.RevokeToken(c, userID)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Revoke feed token successfully", nil)
+}
+
+func (h *FeedHandler) GetTokenStatus(c *gin.Context) {
+
+	userID := helper.RequestUserID(c)
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	status, err := h.feedService.GetTokenStatus(c, userID)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get feed token successfully", status)
+}
+
+// GetFeed serves "/feeds/:token.ics"; the router only sees one segment, so
+// the extension is stripped here.
+func (h *FeedHandler) GetFeed(c *gin.Context) {
+
+	segment := c.Param("token")
+	if !strings.HasSuffix(segment, ".ics") {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	data, err := h.feedService.GetFeed(c, strings.TrimSuffix(segment, ".ics"))
+	if err != nil {
+		if errors.Is(err, ErrFeedNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
@@ -0,0 +1,16 @@
+package feed
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FeedToken grants read-only access to one user's calendar feed. Only the
+// SHA-256 of the secret is stored; the secret itself is shown once, on rotate.
+type FeedToken struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
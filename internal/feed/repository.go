@@ -0,0 +1,112 @@
+package feed
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FeedRepository interface {
+	Upsert(ctx context.Context, token *FeedToken) error
+	FindByUserID(ctx context.Context, userID string) (*FeedToken, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*FeedToken, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type feedRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFeedRepository(collection *mongo.Collection) (FeedRepository, error) {
+	if err := EnsureFeedIndexes(context.Background(), collection); err != nil {
+		return nil, err
+	}
+	return &feedRepository{
+		collection: collection,
+	}, nil
+}
+
+// Upsert stores the user's token hash, replacing any previous one. The
+// document keeps its _id across rotations, since Mongo does not allow
+// changing it; token.ID is only used when the first token is created.
+func (r *feedRepository) Upsert(ctx context.Context, token *FeedToken) error {
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"user_id": token.UserID},
+		bson.M{
+			"$set": bson.M{
+				"token_hash": token.TokenHash,
+				"created_at": token.CreatedAt,
+			},
+			"$setOnInsert": bson.M{"_id": token.ID},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *feedRepository) FindByUserID(ctx context.Context, userID string) (*FeedToken, error) {
+
+	var token FeedToken
+
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *feedRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*FeedToken, error) {
+
+	var token FeedToken
+
+	err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *feedRepository) DeleteByUserID(ctx context.Context, userID string) error {
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func EnsureFeedIndexes(ctx context.Context, coll *mongo.Collection) error {
+
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetName("by_user").
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().
+				SetName("by_token_hash").
+				SetUnique(true),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
@@ -0,0 +1,15 @@
+package feed
+
+import "time"
+
+type FeedTokenResponse struct {
+	Token     string    `json:"token"`
+	Path      string    `json:"path"`
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeedStatusResponse struct {
+	Active    bool       `json:"active"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
@@ -0,0 +1,19 @@
+package feed
+
+import (
+	"event-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *FeedHandler) {
+	feedGroup := r.Group("api/v1/feeds", middleware.Secured())
+	{
+		feedGroup.GET("/token", handler.GetTokenStatus)
+		feedGroup.POST("/token", handler.RotateToken)
+		feedGroup.DELETE("/token", handler.RevokeToken)
+	}
+
+	// Calendar apps poll this without credentials; the token is the secret.
+	r.GET("/feeds/:token", handler.GetFeed)
+}
@@ -0,0 +1,133 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"event-service/helper"
+	"event-service/internal/event"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrFeedNotFound is returned for unknown or revoked feed tokens.
+var ErrFeedNotFound = errors.New("feed not found")
+
+type FeedService interface {
+	RotateToken(ctx context.Context, userID string) (*FeedTokenResponse, error)
+	RevokeToken(ctx context.Context, userID string) error
+	GetTokenStatus(ctx context.Context, userID string) (*FeedStatusResponse, error)
+	GetFeed(ctx context.Context, token string) ([]byte, error)
+}
+
+type feedService struct {
+	feedRepository FeedRepository
+	eventService   event.EventService
+	publicURL      string
+}
+
+func NewFeedService(repo FeedRepository, es event.EventService, publicURL string) FeedService {
+	return &feedService{
+		feedRepository: repo,
+		eventService:   es,
+		publicURL:      strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// RotateToken issues a new secret for the user, replacing (and so revoking)
+// any previous one.
+func (s *feedService) RotateToken(ctx context.Context, userID string) (*FeedTokenResponse, error) {
+
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+
+	secret, err := helper.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	token := &FeedToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		TokenHash: hashSecret(secret),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.feedRepository.Upsert(ctx, token); err != nil {
+		return nil, err
+	}
+
+	path := "/feeds/" + secret + ".ics"
+
+	resp := &FeedTokenResponse{
+		Token:     secret,
+		Path:      path,
+		CreatedAt: token.CreatedAt,
+	}
+
+	if s.publicURL != "" {
+		resp.URL = s.publicURL + path
+	}
+
+	return resp, nil
+}
+
+func (s *feedService) RevokeToken(ctx context.Context, userID string) error {
+
+	if userID == "" {
+		return errors.New("user_id is required")
+	}
+
+	return s.feedRepository.DeleteByUserID(ctx, userID)
+}
+
+func (s *feedService) GetTokenStatus(ctx context.Context, userID string) (*FeedStatusResponse, error) {
+
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+
+	token, err := s.feedRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return &FeedStatusResponse{Active: false}, nil
+	}
+
+	return &FeedStatusResponse{
+		Active:    true,
+		CreatedAt: &token.CreatedAt,
+	}, nil
+}
+
+// GetFeed renders the calendar of the token's owner from the live events, so
+// edits show up on the subscriber's next refresh.
+func (s *feedService) GetFeed(ctx context.Context, secret string) ([]byte, error) {
+
+	if secret == "" {
+		return nil, ErrFeedNotFound
+	}
+
+	token, err := s.feedRepository.FindByTokenHash(ctx, hashSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, ErrFeedNotFound
+	}
+
+	return s.eventService.ExportCalendar(ctx, token.UserID)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrLeaseLost is returned for writes attempted under a lease that has
+// expired or changed hands.
+var ErrLeaseLost = errors.New("lease lost to another holder")
+
+// Fence makes writes conditional on the lease carried by their context.
+type Fence interface {
+	Do(ctx context.Context, write func(ctx context.Context) error) error
+}
+
+type fence struct {
+	collection *mongo.Collection
+}
+
+// NewFence checks leases in collection, the one the LeaseRepository uses.
+// It relies on multi-document transactions, so MongoDB must run as a replica
+// set.
+func NewFence(collection *mongo.Collection) Fence {
+	return &fence{
+		collection: collection,
+	}
+}
+
+// Do runs write in a transaction that first touches the lease document,
+// matching its holder, fencing token and expiry. A successor acquiring the
+// lease meanwhile conflicts with that write, so write commits only while the
+// lease is still ours. Without a lease in ctx, write runs unfenced.
+func (f *fence) Do(ctx context.Context, write func(ctx context.Context) error) error {
+
+	lease, ok := leaseFrom(ctx)
+	if !ok {
+		return write(ctx)
+	}
+
+	session, err := f.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+
+		now := time.Now()
+
+		res, err := f.collection.UpdateOne(sc,
+			bson.M{
+				"_id":        lease.Name,
+				"holder":     lease.Holder,
+				"token":      lease.Token,
+				"expires_at": bson.M{"$gt": now},
+			},
+			bson.M{"$set": bson.M{"fenced_at": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, ErrLeaseLost
+		}
+
+		return nil, write(sc)
+	})
+
+	return err
+}
@@ -0,0 +1,29 @@
+package leader
+
+import (
+	"event-service/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaderHandler struct {
+	leaderService LeaderService
+}
+
+func NewLeaderHandler(leaderService LeaderService) *LeaderHandler {
+	return &LeaderHandler{
+		leaderService: leaderService,
+	}
+}
+
+func (h *LeaderHandler) GetStatus(c *gin.Context) {
+
+	status, err := h.leaderService.GetStatus(c)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get leader status successfully", status)
+}
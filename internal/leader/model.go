@@ -0,0 +1,15 @@
+package leader
+
+import "time"
+
+// Lease is the lock document a replica must hold to run the scheduler. Token
+// is a fencing token that increases every time the lease changes hands, so
+// work done under a lost lease can be told apart.
+type Lease struct {
+	Name       string    `bson:"_id" json:"name"`
+	Holder     string    `bson:"holder" json:"holder"`
+	Token      int64     `bson:"token" json:"token"`
+	AcquiredAt time.Time `bson:"acquired_at" json:"acquired_at"`
+	RenewedAt  time.Time `bson:"renewed_at" json:"renewed_at"`
+	ExpiresAt  time.Time `bson:"expires_at" json:"expires_at"`
+}
@@ -0,0 +1,110 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LeaseRepository interface {
+	Renew(ctx context.Context, name string, holder string, now time.Time, expiresAt time.Time) (*Lease, error)
+	Acquire(ctx context.Context, name string, holder string, now time.Time, expiresAt time.Time) (*Lease, error)
+	Release(ctx context.Context, name string, holder string, now time.Time) error
+	FindByName(ctx context.Context, name string) (*Lease, error)
+}
+
+type leaseRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLeaseRepository(collection *mongo.Collection) LeaseRepository {
+	return &leaseRepository{
+		collection: collection,
+	}
+}
+
+// Renew extends a lease the holder still owns. It returns nil when the
+// lease is held by someone else or has already expired.
+func (r *leaseRepository) Renew(ctx context.Context, name string, holder string, now time.Time, expiresAt time.Time) (*Lease, error) {
+
+	var lease Lease
+
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": name, "holder": holder, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"renewed_at": now, "expires_at": expiresAt}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&lease)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &lease, nil
+}
+
+// Acquire takes over an expired (or missing) lease and bumps its fencing
+// token. It returns nil while another holder's lease is still valid.
+func (r *leaseRepository) Acquire(ctx context.Context, name string, holder string, now time.Time, expiresAt time.Time) (*Lease, error) {
+
+	var lease Lease
+
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": name, "expires_at": bson.M{"$lte": now}},
+		bson.M{
+			"$set": bson.M{
+				"holder":      holder,
+				"acquired_at": now,
+				"renewed_at":  now,
+				"expires_at":  expiresAt,
+			},
+			"$inc": bson.M{"token": 1},
+		},
+		options.FindOneAndUpdate().
+			SetUpsert(true).
+			SetReturnDocument(options.After),
+	).Decode(&lease)
+	if err != nil {
+		// The upsert collides with the _id of a lease that is still valid.
+		if mongo.IsDuplicateKeyError(err) || err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &lease, nil
+}
+
+// Release expires the holder's lease so another replica can take over
+// without waiting for the TTL.
+func (r *leaseRepository) Release(ctx context.Context, name string, holder string, now time.Time) error {
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "holder": holder},
+		bson.M{"$set": bson.M{"expires_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *leaseRepository) FindByName(ctx context.Context, name string) (*Lease, error) {
+
+	var lease Lease
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&lease)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &lease, nil
+}
@@ -0,0 +1,15 @@
+package leader
+
+import "time"
+
+type LeaderStatusResponse struct {
+	Name       string     `json:"name"`
+	Holder     string     `json:"holder"`
+	Token      int64      `json:"token"`
+	AcquiredAt *time.Time `json:"acquired_at,omitempty"`
+	RenewedAt  *time.Time `json:"renewed_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Expired    bool       `json:"expired"`
+	Instance   string     `json:"instance"`
+	IsLeader   bool       `json:"is_leader"`
+}
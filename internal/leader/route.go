@@ -0,0 +1,16 @@
+package leader
+
+import (
+	"event-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes mounts the leader status endpoint, guarded by admin on top
+// of authentication.
+func RegisterRoutes(r *gin.Engine, handler *LeaderHandler, admin gin.HandlerFunc) {
+	adminGroup := r.Group("api/v1/admin", middleware.Secured(), admin)
+	{
+		adminGroup.GET("/leader", handler.GetStatus)
+	}
+}
@@ -0,0 +1,183 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type LeaderService interface {
+	Run(ctx context.Context)
+	Current() (*Lease, bool)
+	GetStatus(ctx context.Context) (*LeaderStatusResponse, error)
+}
+
+type leaderService struct {
+	leaseRepository LeaseRepository
+	name            string
+	holder          string
+	ttl             time.Duration
+
+	mu    sync.RWMutex
+	lease *Lease
+}
+
+// MinLeaseTTL is the shortest lease NewLeaderService accepts.
+const MinLeaseTTL = 3 * time.Second
+
+// NewLeaderService competes for the lease called name. A dead leader is
+// replaced within ttl; the lease is renewed every ttl/3. ttl must be at
+// least MinLeaseTTL.
+func NewLeaderService(repo LeaseRepository, name string, ttl time.Duration) LeaderService {
+	return &leaderService{
+		leaseRepository: repo,
+		name:            name,
+		holder:          InstanceName(),
+		ttl:             ttl,
+	}
+}
+
+// Run keeps acquiring or renewing the lease until ctx is done, then releases
+// it so another replica can take over straight away.
+func (s *leaderService) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(s.ttl / 3)
+	defer ticker.Stop()
+
+	s.renew(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.release()
+			return
+		case <-ticker.C:
+			s.renew(ctx)
+		}
+	}
+}
+
+func (s *leaderService) renew(ctx context.Context) {
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+
+	lease, err := s.leaseRepository.Renew(ctx, s.name, s.holder, now, expiresAt)
+	if err == nil && lease == nil {
+		lease, err = s.leaseRepository.Acquire(ctx, s.name, s.holder, now, expiresAt)
+	}
+
+	if err != nil {
+		// Keep the current lease; Current stops reporting it once it expires.
+		log.Printf("❌ Lease %s renew error: %v", s.name, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wasLeader := s.lease != nil
+	s.lease = lease
+
+	switch {
+	case lease != nil && !wasLeader:
+		log.Printf("👑 %s acquired lease %s (token=%d)", s.holder, s.name, lease.Token)
+	case lease == nil && wasLeader:
+		log.Printf("⚠️ %s lost lease %s", s.holder, s.name)
+	}
+}
+
+func (s *leaderService) release() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lease == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.leaseRepository.Release(ctx, s.name, s.holder, time.Now()); err != nil {
+		log.Printf("❌ Lease %s release error: %v", s.name, err)
+	}
+
+	s.lease = nil
+}
+
+// Current returns the lease while this instance holds it and it has not
+// expired locally.
+func (s *leaderService) Current() (*Lease, bool) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.lease == nil || !time.Now().Before(s.lease.ExpiresAt) {
+		return nil, false
+	}
+
+	lease := *s.lease
+	return &lease, true
+}
+
+func (s *leaderService) GetStatus(ctx context.Context) (*LeaderStatusResponse, error) {
+
+	lease, err := s.leaseRepository.FindByName(ctx, s.name)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &LeaderStatusResponse{
+		Name:     s.name,
+		Instance: s.holder,
+		Expired:  true,
+	}
+
+	if lease == nil {
+		return status, nil
+	}
+
+	status.Holder = lease.Holder
+	status.Token = lease.Token
+	status.AcquiredAt = &lease.AcquiredAt
+	status.RenewedAt = &lease.RenewedAt
+	status.ExpiresAt = &lease.ExpiresAt
+	status.Expired = !time.Now().Before(lease.ExpiresAt)
+	status.IsLeader = lease.Holder == s.holder && !status.Expired
+
+	return status, nil
+}
+
+type leaseKey struct{}
+
+// WithLease carries the lease in ctx. Claims made during a tick record its
+// fencing token and go through a Fence, which refuses them once the lease
+// is lost.
+func WithLease(ctx context.Context, lease *Lease) context.Context {
+	return context.WithValue(ctx, leaseKey{}, *lease)
+}
+
+func leaseFrom(ctx context.Context) (Lease, bool) {
+	lease, ok := ctx.Value(leaseKey{}).(Lease)
+	return lease, ok
+}
+
+// FencingToken returns the fencing token carried by ctx, or 0.
+func FencingToken(ctx context.Context) int64 {
+	lease, _ := leaseFrom(ctx)
+	return lease.Token
+}
+
+// InstanceName identifies this process, as a lease holder and wherever else
+// replicas record which of them did something.
+func InstanceName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"event-service/internal/user"
+	"event-service/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets a request through only when the caller holds role. It
+// runs after Secured and looks the caller up in the main service with their
+// own token, which the main service verifies, so the role cannot be forged
+// in the JWT.
+func RequireRole(us user.UserService, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString(constants.UserID)
+		token := c.GetString(constants.Token)
+		if userID == "" || token == "" {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(c.Request.Context(), constants.TokenKey, token)
+
+		info, err := us.GetUserInfor(ctx, userID)
+		if err != nil {
+			log.Printf("❌ Role check for user %s failed: %v", userID, err)
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		if !info.HasRole(role) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
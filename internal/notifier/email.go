@@ -0,0 +1,271 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"event-service/config"
+	"event-service/internal/user"
+)
+
+// ChannelEmail sends reminders by email to the address on the user's
+// profile in the main service.
+const ChannelEmail = "email"
+
+// smtpTimeout bounds one SMTP session when the caller sets no earlier
+// deadline.
+const smtpTimeout = 30 * time.Second
+
+//go:embed templates/reminder.txt templates/reminder.html
+var emailTemplates embed.FS
+
+// emailData is what the email templates render.
+type emailData struct {
+	Labels     Labels
+	FullName   string
+	Title      string
+	Body       string
+	EventName  string
+	Note       string
+	URL        string
+	LocalStart string
+}
+
+type emailNotifier struct {
+	smtp        config.SMTP
+	userService user.UserService
+	text        *texttemplate.Template
+	html        *htmltemplate.Template
+}
+
+// NewEmailNotifier sends through the configured SMTP server, upgrading to
+// TLS with STARTTLS when the server offers it.
+func NewEmailNotifier(cfg config.SMTP, us user.UserService) Notifier {
+	return &emailNotifier{
+		smtp:        cfg,
+		userService: us,
+		text:        texttemplate.Must(texttemplate.ParseFS(emailTemplates, "templates/reminder.txt")),
+		html:        htmltemplate.Must(htmltemplate.ParseFS(emailTemplates, "templates/reminder.html")),
+	}
+}
+
+func (n *emailNotifier) Channel() string {
+	return ChannelEmail
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, notification *Notification) ([]Result, error) {
+
+	info, err := n.userService.GetUserInfor(ctx, notification.UserID)
+	if err != nil {
+		log.Printf("❌ GetUserInfor error for user %s: %v", notification.UserID, err)
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+
+	if info.Email == "" {
+		log.Printf("📭 No email found for user %s", notification.UserID)
+		return nil, ErrNoTargets
+	}
+
+	data := newEmailData(notification, info.FullName)
+
+	var text, html bytes.Buffer
+	if err := n.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := n.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	messageID, err := newMessageID(n.smtp.From)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := buildEmail(n.smtp.From, info.Email, notification.Title, messageID, text.Bytes(), html.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.send(ctx, info.Email, msg)
+	if err != nil {
+		log.Printf("❌ Failed to email user %s: %v", notification.UserID, err)
+	} else {
+		log.Printf("✅ Emailed user %s (message: %s)", notification.UserID, messageID)
+	}
+
+	return []Result{{Target: info.Email, MessageID: messageID, Err: err}}, nil
+}
+
+// send delivers msg over one SMTP session. The whole exchange must finish
+// by ctx's deadline, or within smtpTimeout, so a stalled server cannot hold
+// up the cron tick.
+func (n *emailNotifier) send(ctx context.Context, to string, msg []byte) error {
+
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	addr := net.JoinHostPort(n.smtp.Host, n.smtp.Port)
+
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// Cancelling ctx interrupts whatever command is in flight.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	c, err := smtp.NewClient(conn, n.smtp.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.smtp.Host}); err != nil {
+			return err
+		}
+	}
+
+	if n.smtp.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", n.smtp.Username, n.smtp.Password, n.smtp.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(n.smtp.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func newEmailData(n *Notification, fullName string) *emailData {
+	return &emailData{
+		Labels:     n.Subject.Labels,
+		FullName:   fullName,
+		Title:      n.Title,
+		Body:       n.Body,
+		EventName:  n.Subject.EventName,
+		Note:       n.Subject.Note,
+		URL:        n.Subject.URL,
+		LocalStart: n.Subject.LocalStart,
+	}
+}
+
+// buildEmail renders a multipart/alternative message with a plain-text and
+// an HTML part, both quoted-printable.
+func buildEmail(from string, to string, subject string, messageID string, text []byte, html []byte) ([]byte, error) {
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	}
+
+	for _, p := range parts {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(p.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := []string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageID,
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	msg.WriteString(strings.Join(headers, "\r\n"))
+	msg.WriteString("\r\n\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+func newMessageID(from string) (string, error) {
+
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	domain := "event-service"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+	if domain == "" {
+		return "", errors.New("invalid sender address")
+	}
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain), nil
+}
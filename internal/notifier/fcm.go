@@ -0,0 +1,335 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"event-service/internal/user"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+)
+
+// ChannelFCM delivers push notifications to the user's devices through
+// Firebase Cloud Messaging.
+const ChannelFCM = "fcm"
+
+// ErrTokenPruned is the result for a device token skipped because FCM
+// already rejected it as dead.
+var ErrTokenPruned = errors.New("device token pruned")
+
+// maxMulticastTokens is the most tokens FCM accepts in one multicast send.
+const maxMulticastTokens = 500
+
+type fcmNotifier struct {
+	fireBase    *firebase.App
+	userService user.UserService
+	tombstones  *tombstones
+
+	mu     sync.Mutex
+	client *messaging.Client
+}
+
+func NewFCMNotifier(fb *firebase.App, us user.UserService) Notifier {
+	return &fcmNotifier{
+		fireBase:    fb,
+		userService: us,
+		tombstones:  newTombstones(),
+	}
+}
+
+func (n *fcmNotifier) Channel() string {
+	return ChannelFCM
+}
+
+// messagingClient returns the shared messaging client, creating it on first
+// use. A failed creation is retried on the next call.
+func (n *fcmNotifier) messagingClient(ctx context.Context) (*messaging.Client, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.client != nil {
+		return n.client, nil
+	}
+
+	client, err := n.fireBase.Messaging(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	n.client = client
+	return client, nil
+}
+
+func (n *fcmNotifier) Notify(ctx context.Context, notification *Notification) ([]Result, error) {
+
+	tokens, err := n.userService.GetTokenUser(ctx, notification.UserID)
+	if err != nil || tokens == nil {
+		log.Printf("❌ GetTokenUser error for user %s: %v", notification.UserID, err)
+		if err == nil {
+			err = errors.New("no tokens returned")
+		}
+		return nil, &TransportError{Err: fmt.Errorf("get tokens: %w", err)}
+	}
+
+	live := n.liveTokens(ctx, notification.UserID, *tokens)
+	if len(live) == 0 {
+		log.Printf("📵 No tokens found for user %s", notification.UserID)
+		return nil, ErrNoTargets
+	}
+
+	results := make([]Result, 0, len(live))
+
+	client, err := n.messagingClient(ctx)
+	if err != nil {
+		log.Printf("❌ Firebase client error: %v", err)
+		err = &TransportError{Err: fmt.Errorf("messaging client: %w", err)}
+		for _, token := range live {
+			results = append(results, Result{Target: token, Err: err})
+		}
+		return results, nil
+	}
+
+	successCount := 0
+	for start := 0; start < len(live); start += maxMulticastTokens {
+		batch := live[start:min(start+maxMulticastTokens, len(live))]
+
+		response, err := client.SendEachForMulticast(ctx, fcmMulticast(notification, batch))
+		if err != nil {
+			log.Printf("❌ Failed to send batch of %d tokens: %v", len(batch), err)
+			err = &TransportError{Err: err}
+			for _, token := range batch {
+				results = append(results, Result{Target: token, Err: err})
+			}
+			continue
+		}
+
+		// When FCM rejects every token as invalid, the message is at fault
+		// (an oversized payload, a bad image URL), not the tokens.
+		payloadRejected := rejectedPayload(response)
+		if payloadRejected {
+			log.Printf("⛔ FCM rejected the payload for user %s: %v", notification.UserID, response.Responses[0].Error)
+		}
+
+		for i, token := range batch {
+			r := response.Responses[i]
+			results = append(results, Result{Target: token, MessageID: r.MessageID, Err: r.Error})
+			if r.Error != nil {
+				log.Printf("❌ Failed to send to token %s: %v", token, r.Error)
+				if !payloadRejected {
+					n.pruneIfDead(ctx, notification.UserID, token, r.Error)
+				}
+			} else {
+				log.Printf("✅ Sent notification to token %s (response: %s)", token, r.MessageID)
+				successCount++
+			}
+		}
+	}
+
+	log.Printf("📊 User %s: sent %d/%d notifications successfully", notification.UserID, successCount, len(live))
+
+	return results, nil
+}
+
+// NotifyTarget sends the notification to a single device token.
+func (n *fcmNotifier) NotifyTarget(ctx context.Context, notification *Notification, target string) Result {
+
+	if n.tombstones.has(target, time.Now()) {
+		return Result{Target: target, Err: ErrTokenPruned}
+	}
+
+	client, err := n.messagingClient(ctx)
+	if err != nil {
+		log.Printf("❌ Firebase client error: %v", err)
+		return Result{Target: target, Err: &TransportError{Err: err}}
+	}
+
+	msg := &messaging.Message{
+		Notification: fcmNotification(notification),
+		Data:         notification.Data,
+		Android:      fcmAndroid(notification),
+		APNS:         fcmAPNS(notification),
+		Token:        target,
+	}
+
+	// A single send cannot tell an invalid token from an invalid payload,
+	// so only unregistered tokens are pruned here.
+	response, err := client.Send(ctx, msg)
+	if messaging.IsUnregistered(err) {
+		n.pruneIfDead(ctx, notification.UserID, target, err)
+	}
+
+	return Result{Target: target, MessageID: response, Err: err}
+}
+
+// liveTokens drops empty and tombstoned tokens, reporting tombstoned ones to
+// the main service again while their removal is unconfirmed.
+func (n *fcmNotifier) liveTokens(ctx context.Context, userID string, tokens []string) []string {
+
+	now := time.Now()
+
+	var live []string
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+
+		if n.tombstones.has(token, now) {
+			log.Printf("🪦 Skipping pruned token %s for user %s", token, userID)
+			n.reportDeadToken(ctx, userID, token, now)
+			continue
+		}
+
+		live = append(live, token)
+	}
+
+	return live
+}
+
+// rejectedPayload reports whether every send of a batch failed as an
+// invalid argument.
+func rejectedPayload(response *messaging.BatchResponse) bool {
+
+	if response.FailureCount == 0 || response.SuccessCount > 0 {
+		return false
+	}
+
+	for _, r := range response.Responses {
+		if !messaging.IsInvalidArgument(r.Error) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pruneIfDead tombstones a token FCM reported as unregistered or invalid and
+// reports it to the main service. FCM answers invalid-argument for malformed
+// payloads too, so callers only pass such errors when the batch shows the
+// payload was accepted.
+func (n *fcmNotifier) pruneIfDead(ctx context.Context, userID string, token string, err error) {
+
+	if !messaging.IsUnregistered(err) && !messaging.IsInvalidArgument(err) {
+		return
+	}
+
+	now := time.Now()
+	n.tombstones.add(token, now)
+	n.reportDeadToken(ctx, userID, token, now)
+}
+
+func (n *fcmNotifier) reportDeadToken(ctx context.Context, userID string, token string, now time.Time) {
+
+	if !n.tombstones.dueReport(token, now) {
+		return
+	}
+
+	if err := n.userService.RemoveTokenUser(ctx, userID, token); err != nil {
+		log.Printf("❌ Error removing dead token %s for user %s: %v", token, userID, err)
+		return
+	}
+
+	log.Printf("🧹 Removed dead token %s for user %s", token, userID)
+	n.tombstones.remove(token)
+}
+
+// Retryable reports whether FCM may accept the send later: it could not be
+// reached, was unavailable, failed internally or throttled the project.
+func (n *fcmNotifier) Retryable(err error) bool {
+	return IsTransport(err) ||
+		messaging.IsUnavailable(err) ||
+		messaging.IsInternal(err) ||
+		messaging.IsQuotaExceeded(err)
+}
+
+func fcmMulticast(notification *Notification, tokens []string) *messaging.MulticastMessage {
+	return &messaging.MulticastMessage{
+		Notification: fcmNotification(notification),
+		Data:         notification.Data,
+		Android:      fcmAndroid(notification),
+		APNS:         fcmAPNS(notification),
+		Tokens:       tokens,
+	}
+}
+
+func fcmNotification(notification *Notification) *messaging.Notification {
+	return &messaging.Notification{
+		Title: notification.Title,
+		Body:  notification.Body,
+	}
+}
+
+func fcmAndroid(notification *Notification) *messaging.AndroidConfig {
+
+	push := notification.Push
+
+	config := &messaging.AndroidConfig{
+		Priority: "normal",
+		Notification: &messaging.AndroidNotification{
+			ClickAction: notification.Category,
+			ChannelID:   push.ChannelID,
+			ImageURL:    push.ImageURL,
+			Tag:         push.ThreadID,
+			Priority:    messaging.PriorityDefault,
+		},
+	}
+
+	if push.Sound != "" {
+		config.Notification.Sound = push.Sound
+	} else {
+		config.Notification.DefaultSound = true
+	}
+
+	if push.TimeSensitive {
+		config.Priority = "high"
+		config.Notification.Priority = messaging.PriorityHigh
+	}
+
+	return config
+}
+
+func fcmAPNS(notification *Notification) *messaging.APNSConfig {
+
+	push := notification.Push
+
+	sound := push.Sound
+	if sound == "" {
+		sound = "default"
+	}
+
+	interruptionLevel := "active"
+	priority := "5"
+	if push.TimeSensitive {
+		interruptionLevel = "time-sensitive"
+		priority = "10"
+	}
+
+	config := &messaging.APNSConfig{
+		Headers: map[string]string{
+			"apns-push-type": "alert",
+			"apns-priority":  priority,
+		},
+		Payload: &messaging.APNSPayload{
+			Aps: &messaging.Aps{
+				Category: notification.Category,
+				Sound:    sound,
+				ThreadID: push.ThreadID,
+				CustomData: map[string]interface{}{
+					"interruption-level": interruptionLevel,
+				},
+			},
+		},
+	}
+
+	if push.ImageURL != "" {
+		// The app's notification service extension downloads the image.
+		config.Payload.Aps.MutableContent = true
+		config.FCMOptions = &messaging.APNSFCMOptions{ImageURL: push.ImageURL}
+	}
+
+	return config
+}
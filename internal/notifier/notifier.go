@@ -0,0 +1,149 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrNoTargets is returned when the recipient has nothing to deliver to on a
+// channel, e.g. no registered device.
+var ErrNoTargets = errors.New("no delivery targets for user")
+
+// Notification is a channel-agnostic reminder for one recipient. Channels
+// render what they support and ignore the rest.
+type Notification struct {
+	// ID identifies one send; it stays the same when a failed send is
+	// retried, so receivers can deduplicate.
+	ID       string
+	UserID   string
+	Title    string
+	Body     string
+	Category string
+	Data     map[string]string
+	Subject  Subject
+	Push     Push
+}
+
+// Push is how a push notification is presented on the device.
+type Push struct {
+	// Sound is the name of the sound bundled in the apps; empty plays the
+	// default one.
+	Sound string
+	// ChannelID is the Android notification channel, which fixes the sound
+	// on Android 8 and later.
+	ChannelID string
+	// ImageURL is shown expanded with the notification.
+	ImageURL string
+	// ThreadID groups notifications about one event on iOS.
+	ThreadID string
+	// TimeSensitive lets the notification break through iOS focus modes and
+	// sends it at high priority on Android.
+	TimeSensitive bool
+}
+
+// Subject describes the event occurrence a notification is about, for
+// channels that render more than a title and body.
+type Subject struct {
+	EventID    string
+	EventName  string
+	Note       string
+	URL        string
+	TimeZone   string
+	AllDay     bool
+	Occurrence time.Time
+	Trigger    string
+	Late       bool
+	// LocalStart is the occurrence start in the event's time zone, written
+	// out in the reminder's locale.
+	LocalStart string
+	// Labels is the text channels may add around the title and body, in
+	// the reminder's locale.
+	Labels Labels
+}
+
+// Labels name the parts of a reminder in one locale. Greeting is used when
+// the recipient's name is unknown; GreetingName takes the name.
+type Labels struct {
+	Greeting     string
+	GreetingName string
+	Event        string
+	Time         string
+	Note         string
+	Link         string
+}
+
+// TransportError is a failure to reach the channel's service, or to look up
+// the recipient's targets, rather than a failure of any one target. It says
+// nothing about whether the send would succeed later, so retriers treat it as
+// retryable.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransport reports whether err is or wraps a TransportError.
+func IsTransport(err error) bool {
+	var te *TransportError
+	return errors.As(err, &te)
+}
+
+// Result is the outcome of delivering to one target of a channel, such as
+// one device token.
+type Result struct {
+	Target    string
+	MessageID string
+	Err       error
+}
+
+// Notifier delivers notifications over one channel. Notify returns an error
+// only when nothing could be attempted; per-target failures are in the
+// results.
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, n *Notification) ([]Result, error)
+}
+
+// Retrier is implemented by channels that can redeliver to a single target
+// after a failure. Retryable tells transient failures, worth another attempt,
+// from permanent ones.
+type Retrier interface {
+	NotifyTarget(ctx context.Context, n *Notification, target string) Result
+	Retryable(err error) bool
+}
+
+// Registry holds the notifiers reminders can target, by channel name.
+type Registry struct {
+	notifiers map[string]Notifier
+}
+
+func NewRegistry(notifiers ...Notifier) *Registry {
+	r := &Registry{notifiers: make(map[string]Notifier, len(notifiers))}
+	for _, n := range notifiers {
+		r.notifiers[n.Channel()] = n
+	}
+	return r
+}
+
+func (r *Registry) Get(channel string) (Notifier, bool) {
+	n, ok := r.notifiers[channel]
+	return n, ok
+}
+
+// Channels returns the registered channel names, sorted.
+func (r *Registry) Channels() []string {
+	channels := make([]string, 0, len(r.notifiers))
+	for name := range r.notifiers {
+		channels = append(channels, name)
+	}
+	sort.Strings(channels)
+	return channels
+}
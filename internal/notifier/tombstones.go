@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"sync"
+	"time"
+)
+
+// A token whose removal the main service has not confirmed is reported again
+// at most every tombstoneRetryInterval, and forgotten after tombstoneTTL so
+// the cache cannot grow without bound.
+const (
+	tombstoneRetryInterval = 10 * time.Minute
+	tombstoneTTL           = 7 * 24 * time.Hour
+)
+
+type tombstone struct {
+	createdAt  time.Time
+	reportedAt time.Time
+}
+
+// tombstones remembers device tokens FCM rejected as dead, so they are
+// skipped until the main service confirms it removed them.
+type tombstones struct {
+	mu     sync.Mutex
+	tokens map[string]*tombstone
+}
+
+func newTombstones() *tombstones {
+	return &tombstones{tokens: make(map[string]*tombstone)}
+}
+
+func (t *tombstones) add(token string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for tok, ts := range t.tokens {
+		if now.Sub(ts.createdAt) > tombstoneTTL {
+			delete(t.tokens, tok)
+		}
+	}
+
+	if _, ok := t.tokens[token]; !ok {
+		t.tokens[token] = &tombstone{createdAt: now}
+	}
+}
+
+// has reports whether token is tombstoned, dropping the tombstone once it
+// is older than tombstoneTTL.
+func (t *tombstones) has(token string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ts, ok := t.tokens[token]
+	if !ok {
+		return false
+	}
+
+	if now.Sub(ts.createdAt) > tombstoneTTL {
+		delete(t.tokens, token)
+		return false
+	}
+
+	return true
+}
+
+// dueReport reports whether the token's removal should be (re)sent to the
+// main service now, and marks it as reported.
+func (t *tombstones) dueReport(token string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ts, ok := t.tokens[token]
+	if !ok {
+		return false
+	}
+
+	if !ts.reportedAt.IsZero() && now.Sub(ts.reportedAt) < tombstoneRetryInterval {
+		return false
+	}
+
+	ts.reportedAt = now
+	return true
+}
+
+// remove forgets a token once the main service confirmed its removal.
+func (t *tombstones) remove(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.tokens, token)
+}
@@ -1,9 +1,24 @@
 package user
 
+import "strings"
+
 type UserInfor struct {
 	UserID     string     `json:"user_id"`
 	UserName   string     `json:"user_name"`
 	FullName   string     `json:"full_name"`
 	Role       string     `json:"role"`
+	Roles      []string   `json:"roles"`
 	Avartar    string     `json:"avatar"`
-}
\ No newline at end of file
+	Email      string     `json:"email"`
+	Locale     string     `json:"locale"`
+}
+
+// HasRole reports whether the user holds role, ignoring case.
+func (u *UserInfor) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
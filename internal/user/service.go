@@ -3,6 +3,7 @@ package user
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"event-service/pkg/constants"
 	"event-service/pkg/consul"
 	"fmt"
@@ -17,6 +18,7 @@ type UserService interface {
 	GetUserInfor(ctx context.Context, userID string) (*UserInfor, error)
 	GetAllUser(ctx context.Context) ([]*UserInfor, error)
 	GetTokenUser(ctx context.Context, userID string) (*[]string, error)
+	RemoveTokenUser(ctx context.Context, userID string, fcmToken string) error
 }
 
 type userService struct {
@@ -76,6 +78,10 @@ func (u *userService) GetTokenUser(ctx context.Context, userID string) (*[]strin
 	return u.client.getTokenUser(ctx, userID)
 }
 
+func (u *userService) RemoveTokenUser(ctx context.Context, userID string, fcmToken string) error {
+	return u.client.removeTokenUser(ctx, userID, fcmToken)
+}
+
 func (u *userService) GetUserInfor(ctx context.Context, userID string) (*UserInfor, error) {
 
 	token, ok := ctx.Value(constants.TokenKey).(string)
@@ -98,12 +104,18 @@ func (u *userService) GetUserInfor(ctx context.Context, userID string) (*UserInf
 	}
 
 	var roleName string
-	rolesRaw, ok := innerData["roles"].([]interface{})
-	if ok && len(rolesRaw) > 0 {
-		firstRole, ok := rolesRaw[0].(map[string]interface{})
-		if ok {
-			roleName = safeString(firstRole["role_name"])
+	var roles []string
+	rolesRaw, _ := innerData["roles"].([]interface{})
+	for _, r := range rolesRaw {
+		role, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := safeString(role["role_name"])
+		if roleName == "" {
+			roleName = name
 		}
+		roles = append(roles, name)
 	}
 
 	return &UserInfor{
@@ -111,7 +123,10 @@ func (u *userService) GetUserInfor(ctx context.Context, userID string) (*UserInf
 		UserName: safeString(innerData["username"]),
 		FullName: safeString(innerData["fullname"]),
 		Avartar:  safeString(innerData["avatar"]),
+		Email:    safeString(innerData["email"]),
+		Locale:   profileLocale(innerData),
 		Role:     roleName,
+		Roles:    roles,
 	}, nil
 }
 
@@ -142,6 +157,15 @@ func (u *userService) GetAllUser(ctx context.Context) ([]*UserInfor, error) {
 	return users, nil
 }
 
+// profileLocale reads the user's preferred language, which the main service
+// exposes as either locale or language.
+func profileLocale(data map[string]interface{}) string {
+	if locale := safeString(data["locale"]); locale != "" {
+		return locale
+	}
+	return safeString(data["language"])
+}
+
 func safeString(val interface{}) string {
 	if val == nil {
 		return ""
@@ -256,3 +280,54 @@ func (c *callAPI) getTokenUser(ctx context.Context, userID string) (*[]string, e
 	return &tokens, nil
 
 }
+
+// removeTokenUser asks the main service to delete a device token FCM no
+// longer accepts. A token the main service does not know counts as removed.
+func (c *callAPI) removeTokenUser(ctx context.Context, userID string, fcmToken string) error {
+
+	token, ok := ctx.Value(constants.TokenKey).(string)
+	if !ok {
+		return fmt.Errorf("token not found in context")
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"user_id": userID,
+		"token":   fcmToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := "/v1/user-token-fcm"
+	header := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + token,
+	}
+	res, err := c.client.CallAPI(c.clientServer, endpoint, http.MethodDelete, body, header)
+	if err != nil {
+		fmt.Printf("Error calling API: %v\n", err)
+		return err
+	}
+
+	var result struct {
+		StatusCode int    `json:"status_code"`
+		Error      string `json:"error"`
+	}
+	err = json.Unmarshal([]byte(res), &result)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling: %v", err)
+	}
+
+	if result.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		if result.Error == "" {
+			return fmt.Errorf("remove token failed with status %d", result.StatusCode)
+		}
+		return errors.New(result.Error)
+	}
+
+	return nil
+}
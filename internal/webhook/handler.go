@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"errors"
+	"event-service/helper"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService WebhookService
+}
+
+func NewWebhookHandler(webhookService WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) SaveTarget(c *gin.Context) {
+
+	userID := helper.RequestUserID(c)
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	var req SaveTargetRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	target, err := h.webhookService.SaveTarget(c, userID, &req)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Save webhook successfully", target)
+}
+
+func (h *WebhookHandler) GetTargets(c *gin.Context) {
+
+	userID := helper.RequestUserID(c)
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	targets, err := h.webhookService.GetTargets(c, userID)
+	if err != nil {
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get webhooks successfully", targets)
+}
+
+func (h *WebhookHandler) DeleteTarget(c *gin.Context) {
+
+	userID := helper.RequestUserID(c)
+	if userID == "" {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("user_id is required"), helper.ErrInvalidRequest)
+		return
+	}
+
+	err := h.webhookService.DeleteTarget(c, userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrTargetNotFound) {
+			helper.SendError(c, http.StatusNotFound, err, helper.ErrInvalidRequest)
+			return
+		}
+		helper.SendError(c, http.StatusInternalServerError, err, helper.ErrInvalidOperation)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete webhook successfully", nil)
+}
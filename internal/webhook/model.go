@@ -0,0 +1,20 @@
+package webhook
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Target is where a user's reminders are POSTed. A target with an EventID
+// applies to that event only and wins over the user's general target. The
+// secret is kept in clear because every delivery is signed with it.
+type Target struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	EventID   string             `bson:"event_id" json:"event_id,omitempty"`
+	URL       string             `bson:"url" json:"url"`
+	Secret    string             `bson:"secret" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,248 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-service/internal/notifier"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Channel is the notifier channel that POSTs reminders to webhook targets.
+const Channel = "webhook"
+
+// Every delivery carries these headers. The signature is "sha256=" followed
+// by the hex HMAC-SHA256, keyed by the target's secret, of
+// "<timestamp>.<body>"; receivers should reject stale timestamps. The ID
+// stays the same across retries so receivers can deduplicate.
+const (
+	HeaderSignature  = "X-Webhook-Signature"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderDeliveryID = "X-Webhook-Id"
+)
+
+// Payload is the JSON body POSTed for a reminder.
+type Payload struct {
+	ID         string             `json:"id"`
+	Type       string             `json:"type"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UserID     string             `json:"user_id"`
+	Title      string             `json:"title"`
+	Body       string             `json:"body"`
+	Event      PayloadEvent       `json:"event"`
+	Occurrence *PayloadOccurrence `json:"occurrence,omitempty"`
+	Trigger    string             `json:"trigger,omitempty"`
+	Late       bool               `json:"late"`
+	Data       map[string]string  `json:"data,omitempty"`
+}
+
+type PayloadEvent struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Note     string `json:"note,omitempty"`
+	URL      string `json:"url,omitempty"`
+	TimeZone string `json:"time_zone"`
+	AllDay   bool   `json:"all_day"`
+}
+
+type PayloadOccurrence struct {
+	Start      time.Time `json:"start"`
+	LocalStart string    `json:"local_start"`
+}
+
+type webhookNotifier struct {
+	webhookRepository WebhookRepository
+	client            *http.Client
+}
+
+// NewNotifier delivers each reminder with a single POST. Transient failures
+// (network errors, 429 and 5xx answers) are retried through the reminder
+// retry queue rather than inside the cron tick.
+func NewNotifier(repo WebhookRepository, client *http.Client) notifier.Notifier {
+	return &webhookNotifier{
+		webhookRepository: repo,
+		client:            client,
+	}
+}
+
+func (n *webhookNotifier) Channel() string {
+	return Channel
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, notification *notifier.Notification) ([]notifier.Result, error) {
+
+	target, err := n.findTarget(ctx, notification.UserID, notification.Subject.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if target == nil {
+		return nil, notifier.ErrNoTargets
+	}
+
+	id, err := n.deliver(ctx, target, notification)
+	if err != nil {
+		log.Printf("❌ Webhook %s for user %s failed: %v", target.URL, notification.UserID, err)
+	} else {
+		log.Printf("✅ Webhook %s delivered for user %s", target.URL, notification.UserID)
+	}
+
+	return []notifier.Result{{Target: target.URL, MessageID: id, Err: err}}, nil
+}
+
+// NotifyTarget retries a failed delivery. The user's current target is
+// used, so a URL fixed since the failure takes effect.
+func (n *webhookNotifier) NotifyTarget(ctx context.Context, notification *notifier.Notification, url string) notifier.Result {
+
+	target, err := n.findTarget(ctx, notification.UserID, notification.Subject.EventID)
+	if err != nil {
+		return notifier.Result{Target: url, Err: err}
+	}
+
+	if target == nil {
+		return notifier.Result{Target: url, Err: permanentError{notifier.ErrNoTargets}}
+	}
+
+	id, err := n.deliver(ctx, target, notification)
+	return notifier.Result{Target: target.URL, MessageID: id, Err: err}
+}
+
+// Retryable reports whether a failed delivery may succeed later: network
+// errors and 429 or 5xx answers, but not other 4xx answers.
+func (n *webhookNotifier) Retryable(err error) bool {
+	_, permanent := err.(permanentError)
+	return !permanent && !errors.Is(err, errBlockedAddress)
+}
+
+// findTarget prefers the target registered for the event over the user's
+// general one.
+func (n *webhookNotifier) findTarget(ctx context.Context, userID string, eventID string) (*Target, error) {
+
+	if eventID != "" {
+		target, err := n.webhookRepository.FindByScope(ctx, userID, eventID)
+		if err != nil || target != nil {
+			return target, err
+		}
+	}
+
+	return n.webhookRepository.FindByScope(ctx, userID, "")
+}
+
+// deliver POSTs the notification once and returns the delivery ID.
+func (n *webhookNotifier) deliver(ctx context.Context, target *Target, notification *notifier.Notification) (string, error) {
+
+	payload := newPayload(notification)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return payload.ID, permanentError{err}
+	}
+
+	return payload.ID, n.post(ctx, target, payload.ID, body)
+}
+
+func (n *webhookNotifier) post(ctx context.Context, target *Target, id string, body []byte) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "event-service-webhook")
+	req.Header.Set(HeaderDeliveryID, id)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(target.Secret, timestamp, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+
+	return permanentError{err}
+}
+
+// Sign returns the signature header value for body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// permanentError marks failures a retry cannot fix, such as a 4xx answer.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+func newPayload(n *notifier.Notification) *Payload {
+
+	subject := n.Subject
+
+	id := n.ID
+	if id == "" {
+		id = primitive.NewObjectID().Hex()
+	}
+
+	payload := &Payload{
+		ID:        id,
+		Type:      "event.reminder",
+		CreatedAt: time.Now().UTC(),
+		UserID:    n.UserID,
+		Title:     n.Title,
+		Body:      n.Body,
+		Event: PayloadEvent{
+			ID:       subject.EventID,
+			Name:     subject.EventName,
+			Note:     subject.Note,
+			URL:      subject.URL,
+			TimeZone: subject.TimeZone,
+			AllDay:   subject.AllDay,
+		},
+		Trigger: subject.Trigger,
+		Late:    subject.Late,
+		Data:    n.Data,
+	}
+
+	if !subject.Occurrence.IsZero() {
+		local := subject.Occurrence
+		if loc, err := time.LoadLocation(subject.TimeZone); err == nil {
+			local = local.In(loc)
+		}
+		payload.Occurrence = &PayloadOccurrence{
+			Start:      subject.Occurrence.UTC(),
+			LocalStart: local.Format("2006-01-02T15:04:05"),
+		}
+	}
+
+	return payload
+}
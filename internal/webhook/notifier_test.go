@@ -0,0 +1,282 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"event-service/internal/notifier"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeRepository struct {
+	target *Target
+}
+
+func (r *fakeRepository) Upsert(ctx context.Context, target *Target) error {
+	r.target = target
+	return nil
+}
+
+func (r *fakeRepository) FindByScope(ctx context.Context, userID string, eventID string) (*Target, error) {
+	if r.target == nil || r.target.UserID != userID || r.target.EventID != eventID {
+		return nil, nil
+	}
+	return r.target, nil
+}
+
+func (r *fakeRepository) FindByUserID(ctx context.Context, userID string) ([]*Target, error) {
+	return []*Target{r.target}, nil
+}
+
+func (r *fakeRepository) Delete(ctx context.Context, userID string, id primitive.ObjectID) (bool, error) {
+	return false, nil
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a server answering with the given statuses in turn,
+// repeating the last one, and records what it was sent.
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []received) {
+
+	var (
+		mu    sync.Mutex
+		calls []received
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		calls = append(calls, received{header: r.Header.Clone(), body: body})
+		status := statuses[min(len(calls), len(statuses))-1]
+		mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]received(nil), calls...)
+	}
+}
+
+func newTestNotifier(srv *httptest.Server) (notifier.Notifier, *Target) {
+	target := &Target{
+		ID:     primitive.NewObjectID(),
+		UserID: "user-1",
+		URL:    srv.URL + "/hook",
+		Secret: "s3cret",
+	}
+	return NewNotifier(&fakeRepository{target: target}, srv.Client()), target
+}
+
+func testNotification() *notifier.Notification {
+	return &notifier.Notification{
+		ID:     primitive.NewObjectID().Hex(),
+		UserID: "user-1",
+		Title:  "🔔 Standup",
+		Body:   "Reminder: Standup is starting soon!",
+		Subject: notifier.Subject{
+			EventID:    primitive.NewObjectID().Hex(),
+			EventName:  "Standup",
+			TimeZone:   "UTC",
+			Occurrence: time.Date(2025, time.June, 2, 9, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestNotifySignsDelivery(t *testing.T) {
+
+	srv, calls := newReceiver(t, http.StatusNoContent)
+	n, target := newTestNotifier(srv)
+	notification := testNotification()
+
+	results, err := n.Notify(context.Background(), notification)
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("results = %+v, want one success", results)
+	}
+
+	got := calls()
+	if len(got) != 1 {
+		t.Fatalf("got %d requests, want 1", len(got))
+	}
+
+	h := got[0].header
+	ts, err := strconv.ParseInt(h.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("bad timestamp header %q", h.Get(HeaderTimestamp))
+	}
+	if want := Sign(target.Secret, ts, got[0].body); h.Get(HeaderSignature) != want {
+		t.Errorf("signature = %q, want %q", h.Get(HeaderSignature), want)
+	}
+	if h.Get(HeaderDeliveryID) != notification.ID {
+		t.Errorf("delivery id = %q, want %q", h.Get(HeaderDeliveryID), notification.ID)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(got[0].body, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.ID != notification.ID || payload.Event.Name != "Standup" || payload.Occurrence == nil {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
+
+func TestNotifyStatuses(t *testing.T) {
+
+	tests := []struct {
+		status    int
+		wantErr   bool
+		retryable bool
+	}{
+		{status: http.StatusOK},
+		{status: http.StatusAccepted},
+		{status: http.StatusBadRequest, wantErr: true},
+		{status: http.StatusGone, wantErr: true},
+		{status: http.StatusTooManyRequests, wantErr: true, retryable: true},
+		{status: http.StatusInternalServerError, wantErr: true, retryable: true},
+		{status: http.StatusServiceUnavailable, wantErr: true, retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+
+			srv, calls := newReceiver(t, tt.status)
+			n, _ := newTestNotifier(srv)
+
+			results, err := n.Notify(context.Background(), testNotification())
+			if err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+
+			// A failing target gets a single attempt per tick.
+			if got := len(calls()); got != 1 {
+				t.Errorf("got %d requests, want 1", got)
+			}
+
+			resErr := results[0].Err
+			if (resErr != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", resErr, tt.wantErr)
+			}
+			if resErr != nil {
+				if got := n.(notifier.Retrier).Retryable(resErr); got != tt.retryable {
+					t.Errorf("Retryable = %v, want %v", got, tt.retryable)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyTargetRetriesWithSameID(t *testing.T) {
+
+	srv, calls := newReceiver(t, http.StatusBadGateway, http.StatusOK)
+	n, target := newTestNotifier(srv)
+	notification := testNotification()
+
+	results, err := n.Notify(context.Background(), notification)
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if results[0].Err == nil {
+		t.Fatal("first delivery succeeded, want 502")
+	}
+
+	retry := n.(notifier.Retrier).NotifyTarget(context.Background(), notification, target.URL)
+	if retry.Err != nil {
+		t.Fatalf("retry: %v", retry.Err)
+	}
+
+	got := calls()
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+	if got[0].header.Get(HeaderDeliveryID) != got[1].header.Get(HeaderDeliveryID) {
+		t.Errorf("delivery id changed across retries: %q, %q",
+			got[0].header.Get(HeaderDeliveryID), got[1].header.Get(HeaderDeliveryID))
+	}
+}
+
+func TestNotifyNetworkErrorIsRetryable(t *testing.T) {
+
+	srv, _ := newReceiver(t, http.StatusOK)
+	n, _ := newTestNotifier(srv)
+	srv.Close()
+
+	results, err := n.Notify(context.Background(), testNotification())
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if results[0].Err == nil || !n.(notifier.Retrier).Retryable(results[0].Err) {
+		t.Errorf("err = %v, want a retryable error", results[0].Err)
+	}
+}
+
+func TestValidateURLRejectsInternalHosts(t *testing.T) {
+
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://hooks.example.com/reminders"},
+		{url: "http://93.184.216.34/hook"},
+		{url: "ftp://hooks.example.com/", wantErr: true},
+		{url: "http://localhost:8080/", wantErr: true},
+		{url: "http://api.localhost/", wantErr: true},
+		{url: "http://127.0.0.1/", wantErr: true},
+		{url: "http://10.1.2.3/", wantErr: true},
+		{url: "http://172.16.0.1/", wantErr: true},
+		{url: "http://192.168.1.1/", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data/", wantErr: true},
+		{url: "http://100.64.0.1/", wantErr: true},
+		{url: "http://0.0.0.0/", wantErr: true},
+		{url: "http://[::1]/", wantErr: true},
+		{url: "http://[fe80::1]/", wantErr: true},
+		{url: "http://[::ffff:127.0.0.1]/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := validateURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("validateURL(%q) = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHTTPClientRefusesInternalAddresses(t *testing.T) {
+
+	srv, calls := newReceiver(t, http.StatusOK)
+
+	target := &Target{UserID: "user-1", URL: srv.URL, Secret: "s3cret"}
+	n := NewNotifier(&fakeRepository{target: target}, NewHTTPClient(time.Second))
+
+	results, err := n.Notify(context.Background(), testNotification())
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if !errors.Is(results[0].Err, errBlockedAddress) {
+		t.Errorf("err = %v, want %v", results[0].Err, errBlockedAddress)
+	}
+	if n.(notifier.Retrier).Retryable(results[0].Err) {
+		t.Error("blocked address reported as retryable")
+	}
+	if got := len(calls()); got != 0 {
+		t.Errorf("server got %d requests, want 0", got)
+	}
+}
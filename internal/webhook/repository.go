@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepository interface {
+	Upsert(ctx context.Context, target *Target) error
+	FindByScope(ctx context.Context, userID string, eventID string) (*Target, error)
+	FindByUserID(ctx context.Context, userID string) ([]*Target, error)
+	Delete(ctx context.Context, userID string, id primitive.ObjectID) (bool, error)
+}
+
+type webhookRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookRepository(collection *mongo.Collection) (WebhookRepository, error) {
+	if err := EnsureWebhookIndexes(context.Background(), collection); err != nil {
+		return nil, err
+	}
+	return &webhookRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *webhookRepository) Upsert(ctx context.Context, target *Target) error {
+
+	_, err := r.collection.ReplaceOne(ctx,
+		bson.M{"user_id": target.UserID, "event_id": target.EventID},
+		target,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindByScope returns the target registered for exactly this user and event
+// ID; an empty event ID is the user's general target.
+func (r *webhookRepository) FindByScope(ctx context.Context, userID string, eventID string) (*Target, error) {
+
+	var target Target
+
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "event_id": eventID}).Decode(&target)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &target, nil
+}
+
+func (r *webhookRepository) FindByUserID(ctx context.Context, userID string) ([]*Target, error) {
+
+	var targets []*Target
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &targets)
+	if err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, userID string, id primitive.ObjectID) (bool, error) {
+
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+
+	return res.DeletedCount == 1, nil
+}
+
+func EnsureWebhookIndexes(ctx context.Context, coll *mongo.Collection) error {
+
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "event_id", Value: 1},
+			},
+			Options: options.Index().
+				SetName("by_user_event").
+				SetUnique(true),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
@@ -0,0 +1,7 @@
+package webhook
+
+type SaveTargetRequest struct {
+	URL          string `json:"url"`
+	EventID      string `json:"event_id,omitempty"`
+	RotateSecret bool   `json:"rotate_secret,omitempty"`
+}
@@ -0,0 +1,12 @@
+package webhook
+
+import "time"
+
+type TargetResponse struct {
+	ID        string    `json:"id"`
+	EventID   string    `json:"event_id,omitempty"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
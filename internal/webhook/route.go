@@ -0,0 +1,16 @@
+package webhook
+
+import (
+	"event-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *WebhookHandler) {
+	webhookGroup := r.Group("api/v1/webhooks", middleware.Secured())
+	{
+		webhookGroup.GET("", handler.GetTargets)
+		webhookGroup.PUT("", handler.SaveTarget)
+		webhookGroup.DELETE("/:id", handler.DeleteTarget)
+	}
+}
@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"event-service/helper"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrTargetNotFound is returned when deleting a target the user does not own.
+var ErrTargetNotFound = errors.New("webhook target not found")
+
+type WebhookService interface {
+	SaveTarget(ctx context.Context, userID string, req *SaveTargetRequest) (*TargetResponse, error)
+	GetTargets(ctx context.Context, userID string) ([]*TargetResponse, error)
+	DeleteTarget(ctx context.Context, userID string, id string) error
+}
+
+type webhookService struct {
+	webhookRepository WebhookRepository
+}
+
+func NewWebhookService(repo WebhookRepository) WebhookService {
+	return &webhookService{
+		webhookRepository: repo,
+	}
+}
+
+// SaveTarget creates or replaces the user's target for req.EventID (or the
+// general one) and returns it with its signing secret. The secret is kept
+// across updates unless rotation is asked for.
+func (s *webhookService) SaveTarget(ctx context.Context, userID string, req *SaveTargetRequest) (*TargetResponse, error) {
+
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+
+	if err := validateURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	if req.EventID != "" {
+		if _, err := primitive.ObjectIDFromHex(req.EventID); err != nil {
+			return nil, fmt.Errorf("invalid event_id: %w", err)
+		}
+	}
+
+	target, err := s.webhookRepository.FindByScope(ctx, userID, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if target == nil {
+		target = &Target{
+			ID:        primitive.NewObjectID(),
+			UserID:    userID,
+			EventID:   req.EventID,
+			CreatedAt: now,
+		}
+	}
+
+	if target.Secret == "" || req.RotateSecret {
+		secret, err := helper.NewSecret()
+		if err != nil {
+			return nil, err
+		}
+		target.Secret = secret
+	}
+
+	target.URL = req.URL
+	target.UpdatedAt = now
+
+	if err := s.webhookRepository.Upsert(ctx, target); err != nil {
+		return nil, err
+	}
+
+	res := toTargetResponse(target)
+	res.Secret = target.Secret
+
+	return res, nil
+}
+
+func (s *webhookService) GetTargets(ctx context.Context, userID string) ([]*TargetResponse, error) {
+
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+
+	targets, err := s.webhookRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*TargetResponse, 0, len(targets))
+	for _, t := range targets {
+		res = append(res, toTargetResponse(t))
+	}
+
+	return res, nil
+}
+
+func (s *webhookService) DeleteTarget(ctx context.Context, userID string, id string) error {
+
+	if userID == "" {
+		return errors.New("user_id is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.webhookRepository.Delete(ctx, userID, objID)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return ErrTargetNotFound
+	}
+
+	return nil
+}
+
+func validateURL(value string) error {
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid url: must be an absolute http(s) URL")
+	}
+
+	if err := validateHost(u.Hostname()); err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	return nil
+}
+
+func toTargetResponse(t *Target) *TargetResponse {
+	return &TargetResponse{
+		ID:        t.ID.Hex(),
+		EventID:   t.EventID,
+		URL:       t.URL,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
@@ -0,0 +1,200 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	DateTimeUTCLayout = "20060102T150405Z"
+	DateTimeLayout    = "20060102T150405"
+	DateLayout        = "20060102"
+
+	// maxLineOctets is the RFC 5545 limit before a content line must be folded.
+	maxLineOctets = 75
+)
+
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Property is a single iCalendar content line such as "DTSTART;TZID=...:...".
+type Property struct {
+	Name   string
+	Params []Param
+	Value  string
+}
+
+// Component is a BEGIN/END block such as VCALENDAR, VEVENT or VALARM.
+type Component struct {
+	Name       string
+	Properties []*Property
+	Components []*Component
+}
+
+func NewComponent(name string) *Component {
+	return &Component{Name: strings.ToUpper(name)}
+}
+
+// AddProperty appends a property with an already encoded value. Use
+// EscapeText for TEXT values.
+func (c *Component) AddProperty(name, value string, params ...Param) *Property {
+	p := &Property{Name: strings.ToUpper(name), Params: params, Value: value}
+	c.Properties = append(c.Properties, p)
+	return p
+}
+
+func (c *Component) AddComponent(child *Component) {
+	c.Components = append(c.Components, child)
+}
+
+// Property returns the first property with the given name, or nil.
+func (c *Component) Property(name string) *Property {
+	name = strings.ToUpper(name)
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// PropertiesNamed returns every property with the given name.
+func (c *Component) PropertiesNamed(name string) []*Property {
+	name = strings.ToUpper(name)
+	var props []*Property
+	for _, p := range c.Properties {
+		if p.Name == name {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// ComponentsNamed returns the direct children with the given name.
+func (c *Component) ComponentsNamed(name string) []*Component {
+	name = strings.ToUpper(name)
+	var children []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// Param returns the value of the named parameter, or "" when absent.
+func (p *Property) Param(name string) string {
+	name = strings.ToUpper(name)
+	for _, param := range p.Params {
+		if strings.ToUpper(param.Name) == name {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// Encode writes c as an RFC 5545 stream with CRLF line endings and folding.
+func Encode(w io.Writer, c *Component) error {
+	bw := bufio.NewWriter(w)
+	if err := encodeComponent(bw, c); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func encodeComponent(w *bufio.Writer, c *Component) error {
+
+	if err := writeLine(w, "BEGIN:"+c.Name); err != nil {
+		return err
+	}
+
+	for _, p := range c.Properties {
+		if err := writeLine(w, p.String()); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range c.Components {
+		if err := encodeComponent(w, child); err != nil {
+			return err
+		}
+	}
+
+	return writeLine(w, "END:"+c.Name)
+}
+
+func (p *Property) String() string {
+
+	var b strings.Builder
+	b.WriteString(p.Name)
+
+	for _, param := range p.Params {
+		b.WriteByte(';')
+		b.WriteString(strings.ToUpper(param.Name))
+		b.WriteByte('=')
+		b.WriteString(quoteParam(stripControls(param.Value)))
+	}
+
+	b.WriteByte(':')
+	b.WriteString(stripControls(p.Value))
+
+	return b.String()
+}
+
+// writeLine folds a content line at 75 octets without splitting a UTF-8
+// sequence; continuation lines start with a single space.
+func writeLine(w *bufio.Writer, line string) error {
+
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		if _, err := w.WriteString(line[:cut] + "\r\n "); err != nil {
+			return err
+		}
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = maxLineOctets - 1
+	}
+
+	_, err := w.WriteString(line + "\r\n")
+	return err
+}
+
+func quoteParam(value string) string {
+	if strings.ContainsAny(value, ":;,") {
+		return `"` + strings.ReplaceAll(value, `"`, "") + `"`
+	}
+	return value
+}
+
+// stripControls drops the control characters RFC 5545 forbids in values,
+// so no value can end its content line and start another.
+func stripControls(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// EscapeText encodes a TEXT property value.
+func EscapeText(value string) string {
+	return textEscaper.Replace(value)
+}
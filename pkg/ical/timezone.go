@@ -0,0 +1,89 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timezone builds a VTIMEZONE for loc listing every UTC offset transition
+// between from and to, so clients without the IANA database can still resolve
+// TZID-qualified times in that range.
+func Timezone(loc *time.Location, from, to time.Time) *Component {
+
+	tz := NewComponent("VTIMEZONE")
+	tz.AddProperty("TZID", loc.String())
+
+	initial := from.In(loc)
+	name, offset := initial.Zone()
+	tz.AddComponent(observance(initial.IsDST(), time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC), offset, offset, name))
+
+	for _, t := range transitions(loc, from, to) {
+		before := t.Add(-time.Second)
+		_, offsetFrom := before.Zone()
+		nameTo, offsetTo := t.Zone()
+		onset := t.In(time.FixedZone("", offsetFrom))
+		tz.AddComponent(observance(t.IsDST(), onset, offsetFrom, offsetTo, nameTo))
+	}
+
+	return tz
+}
+
+func observance(dst bool, onset time.Time, offsetFrom, offsetTo int, name string) *Component {
+
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+
+	c := NewComponent(kind)
+	c.AddProperty("DTSTART", onset.Format(DateTimeLayout))
+	c.AddProperty("TZOFFSETFROM", formatOffset(offsetFrom))
+	c.AddProperty("TZOFFSETTO", formatOffset(offsetTo))
+	if name != "" {
+		c.AddProperty("TZNAME", EscapeText(name))
+	}
+
+	return c
+}
+
+// transitions returns the instants in [from, to] at which loc changes its UTC
+// offset. Days are scanned first, then the changing day is bisected to the
+// second.
+func transitions(loc *time.Location, from, to time.Time) []time.Time {
+
+	var result []time.Time
+
+	prev := from.In(loc).Truncate(time.Second)
+	_, prevOffset := prev.Zone()
+
+	for day := prev.Add(24 * time.Hour); !prev.After(to); day = day.Add(24 * time.Hour) {
+		_, offset := day.In(loc).Zone()
+		if offset != prevOffset {
+			lo, hi := prev, day
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.In(loc).Zone(); o == prevOffset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			if !hi.After(to) {
+				result = append(result, hi.In(loc))
+			}
+			prevOffset = offset
+		}
+		prev = day
+	}
+
+	return result
+}
+
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, (seconds%3600)/60)
+}
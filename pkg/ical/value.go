@@ -0,0 +1,134 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var textUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+// UnescapeText decodes a TEXT property value.
+func UnescapeText(value string) string {
+	return textUnescaper.Replace(value)
+}
+
+// ParseDateTime parses a DATE or DATE-TIME value. UTC values ("...Z") ignore
+// loc; floating and TZID-qualified values are read in loc. dateOnly reports a
+// DATE value, which is returned as local midnight.
+func ParseDateTime(value string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+
+	value = strings.TrimSpace(value)
+
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse(DateTimeUTCLayout, value)
+	case strings.Contains(value, "T"):
+		t, err = time.ParseInLocation(DateTimeLayout, value, loc)
+	default:
+		t, err = time.ParseInLocation(DateLayout, value, loc)
+		dateOnly = true
+	}
+
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+	}
+
+	return t, dateOnly, nil
+}
+
+// ParseDateTimeList parses a comma-separated list such as an EXDATE value.
+func ParseDateTimeList(value string, loc *time.Location) ([]time.Time, error) {
+
+	var times []time.Time
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		t, _, err := ParseDateTime(item, loc)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+
+	return times, nil
+}
+
+// ParseDuration parses an RFC 5545 duration such as "-PT15M" or "P1DT2H".
+// Days and weeks are nominal, so they are returned as 24h multiples.
+func ParseDuration(value string) (time.Duration, error) {
+
+	s := strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign = -1
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+
+	var total time.Duration
+	inTime := false
+	num := ""
+
+	for _, ch := range s {
+		switch {
+		case ch >= '0' && ch <= '9':
+			num += string(ch)
+			continue
+		case ch == 'T':
+			if inTime || num != "" {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			inTime = true
+			continue
+		}
+
+		if num == "" {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		num = ""
+
+		unit := time.Duration(0)
+		switch {
+		case ch == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case ch == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case ch == 'H' && inTime:
+			unit = time.Hour
+		case ch == 'M' && inTime:
+			unit = time.Minute
+		case ch == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		total += time.Duration(n) * unit
+	}
+
+	if num != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	return sign * total, nil
+}